@@ -0,0 +1,20 @@
+package bootstrap
+
+import (
+	"gin-frame/config"
+	"gin-frame/library/location"
+	"gin-frame/library/product"
+	"gin-frame/models/hangqing/origin_price_model"
+)
+
+//resources model和library中固定使用的mysql连接和redis section，新增时在这里登记
+//app.ini中[cache]、[spy_auth]引用的连接由config.Load自动加入
+var resources = config.Resources{
+	Mysql: []string{origin_price_model.Conn},
+	Redis: []string{location.RedisName, product.RedisName},
+}
+
+//LoadConfig 启动时加载并校验全部配置，所有问题一次性返回
+func LoadConfig() (*config.Config, error) {
+	return config.Load(resources)
+}
@@ -0,0 +1,80 @@
+package bootstrap
+
+import (
+	"gin-frame/cache"
+	"gin-frame/config"
+	"gin-frame/container"
+	"gin-frame/dao/origin_price_dao"
+	"gin-frame/library/alert"
+	"gin-frame/library/httpclient"
+	"gin-frame/library/location"
+	"gin-frame/library/logger"
+	"gin-frame/library/product"
+	"gin-frame/library/redis_client"
+	"gin-frame/library/tracer"
+	"gin-frame/middlewares/auth"
+	"gin-frame/models/base"
+	"gin-frame/models/hangqing/origin_price_model"
+	"gin-frame/service/origin_price_service"
+)
+
+//providers 全部业务组件的构造函数，新增model/dao/library/service在这里注册
+var providers = []interface{}{
+	NewShutdown,
+	NewHealth,
+
+	//library
+	logger.NewLoggers,
+	tracer.NewTracer,
+	alert.NewNotifier,
+	httpclient.NewClient,
+	redis_client.NewRegistry,
+	location.NewLocationLibrary,
+	product.NewProductLibrary,
+	cache.NewCache,
+
+	//model
+	origin_price_model.NewOriginPriceModel,
+
+	//dao
+	origin_price_dao.NewOriginPriceDao,
+
+	//service
+	origin_price_service.NewOriginPriceService,
+
+	//middleware
+	auth.NewSpyStore,
+}
+
+//Register 把providers注册到容器，测试可在Register之后用Replace换成fake再Build
+func Register(c *container.Container) error {
+	for _, p := range providers {
+		if err := c.Provide(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//NewContainer 注册并实例化全部组件，缺失或循环依赖在启动时直接报错
+//cfg为LoadConfig的结果，组件通过*config.Config读取配置
+func NewContainer(cfg *config.Config) (*container.Container, error) {
+	c := container.New()
+	if err := Register(c); err != nil {
+		return nil, err
+	}
+	if err := c.Provide(func() *config.Config { return cfg }); err != nil {
+		return nil, err
+	}
+	base.SetConfig(cfg.Mysql)
+	if err := c.Build(); err != nil {
+		return nil, err
+	}
+	if err := c.Invoke(registerClosers); err != nil {
+		return nil, err
+	}
+	if err := c.Invoke(registerChecks); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
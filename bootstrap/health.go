@@ -0,0 +1,40 @@
+package bootstrap
+
+import (
+	"context"
+
+	"gin-frame/config"
+	"gin-frame/health"
+	"gin-frame/library/redis_client"
+	"gin-frame/models/base"
+)
+
+//NewHealth 按app.ini的[health]创建
+func NewHealth(cfg *config.Config) *health.Checker {
+	return health.New(cfg.Health.Timeout)
+}
+
+//registerChecks 为启动时已创建的mysql连接和redis连接池注册探测
+func registerChecks(checker *health.Checker, redisRegistry *redis_client.Registry) {
+	for _, conn := range base.Conns() {
+		conn := conn
+		checker.Register("mysql:"+conn+"_write", func(ctx context.Context) error {
+			return base.PingWrite(ctx, conn)
+		})
+		checker.Register("mysql:"+conn+"_read", func(ctx context.Context) error {
+			return base.PingRead(ctx, conn)
+		})
+	}
+
+	for _, name := range redisRegistry.Names() {
+		name := name
+		checker.Register("redis:"+name, func(ctx context.Context) error {
+			client, err := redisRegistry.Get(name)
+			if err != nil {
+				return err
+			}
+			_, err = client.Do(ctx, "PING")
+			return err
+		})
+	}
+}
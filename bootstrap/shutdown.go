@@ -0,0 +1,31 @@
+package bootstrap
+
+import (
+	"context"
+
+	"gin-frame/config"
+	"gin-frame/library/alert"
+	"gin-frame/library/logger"
+	"gin-frame/library/redis_client"
+	"gin-frame/library/tracer"
+	"gin-frame/models/base"
+	"gin-frame/shutdown"
+)
+
+//NewShutdown 按app.ini的[shutdown]创建
+func NewShutdown(cfg *config.Config) *shutdown.Coordinator {
+	return shutdown.New(cfg.Shutdown.DrainTimeout, cfg.Shutdown.CloseTimeout)
+}
+
+//registerClosers 注册容器中需要在退出时释放的资源
+func registerClosers(coordinator *shutdown.Coordinator, redisRegistry *redis_client.Registry, notifier *alert.Notifier, loggers *logger.Loggers, t *tracer.Tracer) {
+	coordinator.Register("tracer", shutdown.OrderTracer, t.Close)
+	coordinator.Register("mysql", shutdown.OrderClient, func(ctx context.Context) error {
+		return base.CloseAll()
+	})
+	coordinator.Register("redis", shutdown.OrderClient, func(ctx context.Context) error {
+		return redisRegistry.Close()
+	})
+	coordinator.Register("alert", shutdown.OrderClient, notifier.Close)
+	coordinator.Register("log", shutdown.OrderLog, loggers.Close)
+}
@@ -0,0 +1,171 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gin-frame/config"
+	"gin-frame/library/redis_client"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/opentracing/opentracing-go"
+)
+
+//loadTimeout 回源的超时，回源不跟随发起请求的ctx取消
+const loadTimeout = 3 * time.Second
+
+//notFoundMarker 回源结果为NotFound时写入的占位值，防止缓存穿透
+var notFoundMarker = []byte("\x00nil")
+
+//commander 缓存使用的redis命令，默认为*redis_client.Client，测试中替换为fake
+type commander interface {
+	Do(ctx context.Context, commandName string, args ...interface{}) (interface{}, error)
+}
+
+//Cache 基于redis的读穿透缓存，按Namespace划分key
+type Cache struct {
+	redis       commander
+	prefix      string
+	ttl         time.Duration
+	negativeTtl time.Duration
+	group       *group
+}
+
+//NewCache 按app.ini的[cache]创建
+func NewCache(cfg *config.Config, registry *redis_client.Registry) (*Cache, error) {
+	db, err := registry.Get(cfg.Cache.Redis)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cache{
+		redis:       db,
+		prefix:      cfg.Cache.Prefix,
+		ttl:         cfg.Cache.Ttl,
+		negativeTtl: cfg.Cache.NegativeTtl,
+		group:       &group{},
+	}, nil
+}
+
+//Namespace 创建一个独立的key空间，ttl<=0时使用默认ttl
+//notFound为回源时表示数据不存在的错误，命中占位值时原样返回
+func (c *Cache) Namespace(name string, ttl time.Duration, notFound error) *Namespace {
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
+	return &Namespace{cache: c, name: name, ttl: ttl, notFound: notFound}
+}
+
+type Namespace struct {
+	cache    *Cache
+	name     string
+	ttl      time.Duration
+	notFound error
+}
+
+//Key 完整的redis key，格式为 prefix::cache::namespace:key
+func (ns *Namespace) Key(key string) string {
+	return ns.cache.prefix + "::cache::" + ns.name + ":" + key
+}
+
+//Get 读取key并json解码到dest，未命中时调用load回源并写回缓存
+//noCache为true时跳过读取，直接回源并刷新缓存
+//同一个key并发未命中时只有一个请求回源，load的ctx与请求无关，超时为loadTimeout，trace关联到发起回源的请求
+func (ns *Namespace) Get(ctx context.Context, key string, dest interface{}, noCache bool, load func(ctx context.Context) (interface{}, error)) error {
+	fullKey := ns.Key(key)
+
+	if !noCache {
+		data, err := redigo.Bytes(ns.cache.redis.Do(ctx, "GET", fullKey))
+		switch {
+		case err == nil:
+			return ns.decode(data, dest)
+		case err != redigo.ErrNil:
+			//缓存异常不影响回源
+			log.Printf("cache get %s: %v", fullKey, err)
+		}
+	}
+
+	data, err := ns.cache.group.do(ctx, fullKey, func() ([]byte, error) {
+		loadCtx, finish := ns.detach(ctx)
+		defer finish()
+		return ns.load(loadCtx, fullKey, load)
+	})
+	if err != nil {
+		return err
+	}
+	return ns.decode(data, dest)
+}
+
+//Delete 写操作后主动删除缓存
+func (ns *Namespace) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, len(keys))
+	for i, key := range keys {
+		args[i] = ns.Key(key)
+	}
+	if _, err := ns.cache.redis.Do(ctx, "DEL", args...); err != nil {
+		return fmt.Errorf("cache del %v: %w", args, err)
+	}
+	return nil
+}
+
+//detach 回源由所有等待者共享，第一个请求取消或超时不应让其他等待者一起失败
+//使用独立的ctx，span以FollowsFrom关联到发起回源的请求
+func (ns *Namespace) detach(ctx context.Context) (context.Context, func()) {
+	loadCtx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	parent := opentracing.SpanFromContext(ctx)
+	if parent == nil {
+		return loadCtx, cancel
+	}
+
+	span := parent.Tracer().StartSpan("cache load "+ns.name, opentracing.FollowsFrom(parent.Context()))
+	return opentracing.ContextWithSpan(loadCtx, span), func() {
+		span.Finish()
+		cancel()
+	}
+}
+
+func (ns *Namespace) load(ctx context.Context, fullKey string, load func(ctx context.Context) (interface{}, error)) ([]byte, error) {
+	value, err := load(ctx)
+	if err != nil {
+		if ns.notFound != nil && errors.Is(err, ns.notFound) && ns.cache.negativeTtl > 0 {
+			ns.set(ctx, fullKey, notFoundMarker, ns.cache.negativeTtl)
+			return notFoundMarker, nil
+		}
+		return nil, err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("cache encode %s: %w", fullKey, err)
+	}
+	ns.set(ctx, fullKey, data, ns.ttl)
+	return data, nil
+}
+
+func (ns *Namespace) set(ctx context.Context, fullKey string, data []byte, ttl time.Duration) {
+	seconds := int(ttl / time.Second)
+	if seconds <= 0 {
+		seconds = 1
+	}
+	if _, err := ns.cache.redis.Do(ctx, "SET", fullKey, data, "EX", seconds); err != nil {
+		log.Printf("cache set %s: %v", fullKey, err)
+	}
+}
+
+func (ns *Namespace) decode(data []byte, dest interface{}) error {
+	if string(data) == string(notFoundMarker) {
+		return ns.notFound
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return fmt.Errorf("cache decode %s: %w", ns.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errNotFound = errors.New("not found")
+
+//fakeRedis 只支持GET、SET key value EX seconds和DEL
+type fakeRedis struct {
+	lock sync.Mutex
+	data map[string][]byte
+	ttl  map[string]int
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{data: make(map[string][]byte), ttl: make(map[string]int)}
+}
+
+func (r *fakeRedis) Do(ctx context.Context, commandName string, args ...interface{}) (interface{}, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	switch commandName {
+	case "GET":
+		data, ok := r.data[args[0].(string)]
+		if !ok {
+			//redigo.Bytes把nil转换为redigo.ErrNil
+			return nil, nil
+		}
+		return data, nil
+	case "SET":
+		key := args[0].(string)
+		r.data[key] = args[1].([]byte)
+		r.ttl[key] = args[3].(int)
+		return "OK", nil
+	case "DEL":
+		for _, key := range args {
+			delete(r.data, key.(string))
+		}
+		return int64(len(args)), nil
+	}
+	return nil, errors.New("unsupported command " + commandName)
+}
+
+type item struct {
+	Name string `json:"name"`
+}
+
+func newTestNamespace() (*Namespace, *fakeRedis) {
+	redis := newFakeRedis()
+	c := &Cache{redis: redis, prefix: "test", ttl: time.Minute, negativeTtl: 10 * time.Second, group: &group{}}
+	return c.Namespace("item", 0, errNotFound), redis
+}
+
+//countingLoader 返回value和err，并记录调用次数
+func countingLoader(calls *int32, value interface{}, err error) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(calls, 1)
+		return value, err
+	}
+}
+
+func TestGetCachesValue(t *testing.T) {
+	ns, redis := newTestNamespace()
+	var calls int32
+	load := countingLoader(&calls, &item{Name: "a"}, nil)
+
+	for i := 0; i < 2; i++ {
+		got := &item{}
+		if err := ns.Get(context.Background(), "1", got, false, load); err != nil || got.Name != "a" {
+			t.Fatalf("Get = %+v, %v", got, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("load calls = %d, want 1", calls)
+	}
+	if ttl := redis.ttl[ns.Key("1")]; ttl != 60 {
+		t.Errorf("ttl = %d, want 60", ttl)
+	}
+}
+
+func TestGetSingleflight(t *testing.T) {
+	ns, _ := newTestNamespace()
+	var calls int32
+	release := make(chan struct{})
+	load := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return &item{Name: "a"}, nil
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got := &item{}
+			err := ns.Get(context.Background(), "1", got, false, load)
+			if err == nil && got.Name != "a" {
+				err = errors.New("got " + got.Name)
+			}
+			errs <- err
+		}()
+	}
+	//等待所有请求都未命中并加入同一次回源
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("load calls = %d, want 1", calls)
+	}
+}
+
+func TestGetWaiterCanceled(t *testing.T) {
+	ns, _ := newTestNamespace()
+	release := make(chan struct{})
+	var loadErr error
+	done := make(chan struct{})
+	load := func(ctx context.Context) (interface{}, error) {
+		<-release
+		loadErr = ctx.Err()
+		close(done)
+		return &item{Name: "a"}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() {
+		errc <- ns.Get(ctx, "1", &item{}, false, load)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := <-errc; err != context.Canceled {
+		t.Errorf("Get err = %v, want context.Canceled", err)
+	}
+
+	//回源不随发起请求的ctx取消，完成后写入缓存
+	close(release)
+	<-done
+	if loadErr != nil {
+		t.Errorf("load ctx err = %v, want nil", loadErr)
+	}
+}
+
+func TestGetNegativeCache(t *testing.T) {
+	ns, redis := newTestNamespace()
+	var calls int32
+	load := countingLoader(&calls, nil, errNotFound)
+
+	for i := 0; i < 2; i++ {
+		if err := ns.Get(context.Background(), "1", &item{}, false, load); err != errNotFound {
+			t.Fatalf("Get err = %v, want errNotFound", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("load calls = %d, want 1", calls)
+	}
+	if ttl := redis.ttl[ns.Key("1")]; ttl != 10 {
+		t.Errorf("negative ttl = %d, want 10", ttl)
+	}
+}
+
+func TestGetLoadErrorNotCached(t *testing.T) {
+	ns, redis := newTestNamespace()
+	var calls int32
+	loadErr := errors.New("db down")
+	load := countingLoader(&calls, nil, loadErr)
+
+	for i := 0; i < 2; i++ {
+		if err := ns.Get(context.Background(), "1", &item{}, false, load); err != loadErr {
+			t.Fatalf("Get err = %v, want %v", err, loadErr)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("load calls = %d, want 2", calls)
+	}
+	if _, ok := redis.data[ns.Key("1")]; ok {
+		t.Error("load error was cached")
+	}
+}
+
+func TestGetNoCache(t *testing.T) {
+	ns, _ := newTestNamespace()
+	var calls int32
+	if err := ns.Get(context.Background(), "1", &item{}, false, countingLoader(&calls, &item{Name: "old"}, nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	got := &item{}
+	if err := ns.Get(context.Background(), "1", got, true, countingLoader(&calls, &item{Name: "new"}, nil)); err != nil || got.Name != "new" {
+		t.Fatalf("Get noCache = %+v, %v, want new", got, err)
+	}
+
+	//noCache回源的结果刷新了缓存
+	got = &item{}
+	if err := ns.Get(context.Background(), "1", got, false, countingLoader(&calls, &item{Name: "unused"}, nil)); err != nil || got.Name != "new" {
+		t.Errorf("Get after noCache = %+v, %v, want new", got, err)
+	}
+	if calls != 2 {
+		t.Errorf("load calls = %d, want 2", calls)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	ns, _ := newTestNamespace()
+	var calls int32
+	load := countingLoader(&calls, &item{Name: "a"}, nil)
+
+	if err := ns.Get(context.Background(), "1", &item{}, false, load); err != nil {
+		t.Fatal(err)
+	}
+	if err := ns.Delete(context.Background(), "1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := ns.Get(context.Background(), "1", &item{}, false, load); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("load calls = %d, want 2 after Delete", calls)
+	}
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+type call struct {
+	done chan struct{}
+	val  []byte
+	err  error
+}
+
+//group 同一个key同时只有一个请求回源，其余请求等待并共享结果
+//回源在独立的goroutine中执行，等待者的ctx结束时各自返回，不影响回源和其他等待者
+type group struct {
+	lock  sync.Mutex
+	calls map[string]*call
+}
+
+func (g *group) do(ctx context.Context, key string, fn func() ([]byte, error)) ([]byte, error) {
+	g.lock.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	c, ok := g.calls[key]
+	if !ok {
+		c = &call{done: make(chan struct{})}
+		g.calls[key] = c
+		go g.run(key, c, fn)
+	}
+	g.lock.Unlock()
+
+	select {
+	case <-c.done:
+		return c.val, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (g *group) run(key string, c *call, fn func() ([]byte, error)) {
+	defer func() {
+		if err := recover(); err != nil {
+			c.err = fmt.Errorf("cache load %s panic: %v", key, err)
+		}
+		g.lock.Lock()
+		delete(g.calls, key)
+		g.lock.Unlock()
+		close(c.done)
+	}()
+
+	c.val, c.err = fn()
+}
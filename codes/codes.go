@@ -15,6 +15,7 @@ const ERRNO_MISS_BREED_ID = 1010
 const ERRNO_WRONG_TYPE = 1011
 const ERRNO_MISS_PRODUCT_ID = 1012
 const ERRNO_PARAMS_EMPTY = 1013
+const ERRNO_WRONG_DAY_TIME = 1014
 
 //2XXX，业务验证相关
 const ERRNO_REPEAT_ADD_BREED = 2000
@@ -28,6 +29,7 @@ const NO_AUTHORIZE_SPY = 3001
 //5XXX，服务器错误相关
 const SERVER_ERROR = 5000
 const ERRNO_DATA_ERR = 5001
+const ERRNO_NOT_READY = 5002
 
 var ErrorMsg = map[int]string{
 	//1XXX
@@ -45,6 +47,7 @@ var ErrorMsg = map[int]string{
 	ERRNO_WRONG_TYPE:              "type不合法",
 	ERRNO_MISS_PRODUCT_ID:         "缺少product_id",
 	ERRNO_PARAMS_EMPTY:            "缺少参数",
+	ERRNO_WRONG_DAY_TIME:          "day_time只能是当天",
 
 	//2XXX
 	ERRNO_REPEAT_ADD_BREED:       "重复提交",
@@ -56,8 +59,9 @@ var ErrorMsg = map[int]string{
 	NO_AUTHORIZE_SPY: "不是情报员",
 
 	//5XXX
-	SERVER_ERROR:   "服务器错误",
-	ERRNO_DATA_ERR: "数据错误",
+	SERVER_ERROR:    "服务器错误",
+	ERRNO_DATA_ERR:  "数据错误",
+	ERRNO_NOT_READY: "服务未就绪",
 }
 
 var ErrorUserMsg = map[int]string{
@@ -77,6 +81,7 @@ var ErrorUserMsg = map[int]string{
 	ERRNO_WRONG_TYPE:              "请求参数错误",
 	ERRNO_MISS_PRODUCT_ID:         "请求参数错误",
 	ERRNO_PARAMS_EMPTY:            "请求参数错误",
+	ERRNO_WRONG_DAY_TIME:          "只能提交当天的报价",
 
 	//2XXX
 	ERRNO_REPEAT_ADD_BREED:       "重复提交",
@@ -88,6 +93,7 @@ var ErrorUserMsg = map[int]string{
 	NO_AUTHORIZE_SPY: "您不是情报员，请申请成为情报员",
 
 	//5XXX
-	SERVER_ERROR:   "服务器暂时有点小问题，稍后再试",
-	ERRNO_DATA_ERR: "服务器暂时有点小问题，稍后再试",
+	SERVER_ERROR:    "服务器暂时有点小问题，稍后再试",
+	ERRNO_DATA_ERR:  "服务器暂时有点小问题，稍后再试",
+	ERRNO_NOT_READY: "服务器暂时有点小问题，稍后再试",
 }
@@ -0,0 +1,106 @@
+package codes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+//ErrnoKey 输出结果的errno保存在gin.Context中的key，供日志、监控等中间件读取
+const ErrnoKey = "errno"
+
+//Error 业务错误，携带errno、内部错误信息、用户提示、http状态码和原始错误
+//service层返回*Error，controller通过Fail统一输出
+type Error struct {
+	Errno      int
+	Msg        string
+	UserMsg    string
+	HttpStatus int
+	Cause      error
+}
+
+//New 根据errno创建错误，Msg和UserMsg取自ErrorMsg/ErrorUserMsg
+func New(errno int) *Error {
+	return &Error{
+		Errno:      errno,
+		Msg:        ErrorMsg[errno],
+		UserMsg:    ErrorUserMsg[errno],
+		HttpStatus: httpStatus(errno),
+	}
+}
+
+//Wrap 根据errno创建错误并记录原始错误
+func Wrap(errno int, cause error) *Error {
+	e := New(errno)
+	e.Cause = cause
+	return e
+}
+
+//FromError 把任意error转换为*Error，非*Error按SERVER_ERROR处理
+func FromError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return Wrap(SERVER_ERROR, err)
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("errno %d: %s: %v", e.Errno, e.Msg, e.Cause)
+	}
+	return fmt.Sprintf("errno %d: %s", e.Errno, e.Msg)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+//Is 按errno比较，errors.Is(err, codes.New(codes.ERRNO_DATA_ERR))
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Errno == e.Errno
+}
+
+//WithMsg 返回覆盖了内部错误信息的副本
+func (e *Error) WithMsg(msg string) *Error {
+	c := *e
+	c.Msg = msg
+	return &c
+}
+
+//WithUserMsg 返回覆盖了用户提示的副本
+func (e *Error) WithUserMsg(userMsg string) *Error {
+	c := *e
+	c.UserMsg = userMsg
+	return &c
+}
+
+//WithCause 返回记录了原始错误的副本
+func (e *Error) WithCause(cause error) *Error {
+	c := *e
+	c.Cause = cause
+	return &c
+}
+
+//httpStatus errno按段映射http状态码
+//1XXX参数错误400，2XXX业务校验200，3XXX权限403，5XXX服务器错误500，未就绪503
+func httpStatus(errno int) int {
+	switch {
+	case errno == ERRNO_NOT_READY:
+		return http.StatusServiceUnavailable
+	case errno >= 1000 && errno < 2000:
+		return http.StatusBadRequest
+	case errno >= 2000 && errno < 3000:
+		return http.StatusOK
+	case errno >= 3000 && errno < 4000:
+		return http.StatusForbidden
+	case errno >= 5000:
+		return http.StatusInternalServerError
+	}
+	return http.StatusOK
+}
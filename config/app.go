@@ -0,0 +1,216 @@
+package config
+
+import (
+	"time"
+)
+
+//App app.ini的[app]
+type App struct {
+	Env     string
+	Port    int
+	AppId   string
+	Product string
+	Module  string
+}
+
+func loadApp(s *Section) App {
+	return App{
+		Env:     s.Required("env"),
+		Port:    s.IntRange("port", 0, 1, 65535),
+		AppId:   s.String("app_id", ""),
+		Product: s.Required("product"),
+		Module:  s.Required("module"),
+	}
+}
+
+//Shutdown app.ini的[shutdown]
+//	drain_timeout = 10    等待处理中请求的秒数
+//	close_timeout = 5     每个资源关闭的秒数
+type Shutdown struct {
+	DrainTimeout time.Duration
+	CloseTimeout time.Duration
+}
+
+func loadShutdown(s *Section) Shutdown {
+	return Shutdown{
+		DrainTimeout: s.Duration("drain_timeout", 10, time.Second, 0),
+		CloseTimeout: s.Duration("close_timeout", 5, time.Second, 1),
+	}
+}
+
+//Health app.ini的[health]
+//	timeout = 500    每个依赖探测的毫秒数
+type Health struct {
+	Timeout time.Duration
+}
+
+func loadHealth(s *Section) Health {
+	return Health{Timeout: s.Duration("timeout", 500, time.Millisecond, 1)}
+}
+
+//Alert app.ini的[alert]
+//	sinks = local,file,webhook   逗号分隔，默认local
+//	webhook = http://...         sinks包含webhook时必填
+//	file = ./logs/alert/alert.log
+//	rate = 10                    每分钟最多发送的告警数，0为不限流
+//	timeout = 3000               每个sink发送的毫秒数
+//	dedup_window = 300           同一调用栈的panic在窗口秒数内只告警一次
+type Alert struct {
+	Sinks       []string
+	Webhook     string
+	File        string
+	Rate        int
+	Timeout     time.Duration
+	DedupWindow time.Duration
+}
+
+func loadAlert(s *Section) Alert {
+	cfg := Alert{
+		Sinks:       s.Strings("sinks", "local"),
+		Webhook:     s.String("webhook", ""),
+		File:        s.String("file", "./logs/alert/alert.log"),
+		Rate:        s.IntRange("rate", 10, 0, 1<<20),
+		Timeout:     s.Duration("timeout", 3000, time.Millisecond, 1),
+		DedupWindow: s.Duration("dedup_window", 300, time.Second, 0),
+	}
+	for _, sink := range cfg.Sinks {
+		switch sink {
+		case "local", "file":
+		case "webhook":
+			s.Check(cfg.Webhook != "", "webhook is required when sinks contains webhook")
+		default:
+			s.Check(false, "unknown sink %s", sink)
+		}
+	}
+	return cfg
+}
+
+//Trace app.ini的[trace]，service_name默认取[app]的product
+//	sampler = const              const、probabilistic、ratelimiting、remote
+//	sampler_param = 1            const为0或1，probabilistic为0-1的比例，ratelimiting为每秒span数
+//	reporter = udp               udp、http、memory、file、none
+//	endpoint = 127.0.0.1:6831    udp为agent地址，http为collector地址，如http://127.0.0.1:14268/api/traces
+//	file = ./logs/trace/span.log reporter为file时写入的文件
+//	queue_size = 1000
+//	flush_interval = 1000        毫秒，reporter为file时也按此间隔刷盘
+type Trace struct {
+	ServiceName  string
+	SamplerType  string
+	SamplerParam float64
+	Reporter     string
+	//Endpoint udp为agent的host:port，http为collector的url
+	Endpoint      string
+	File          string
+	QueueSize     int
+	FlushInterval time.Duration
+}
+
+func loadTrace(s *Section, app App) Trace {
+	cfg := Trace{
+		ServiceName:   s.String("service_name", app.Product),
+		SamplerType:   s.OneOf("sampler", "const", "const", "probabilistic", "ratelimiting", "remote"),
+		SamplerParam:  s.Float("sampler_param", 1),
+		Reporter:      s.OneOf("reporter", "none", "udp", "http", "memory", "file", "none"),
+		Endpoint:      s.String("endpoint", ""),
+		File:          s.String("file", "./logs/trace/span.log"),
+		QueueSize:     s.IntRange("queue_size", 1000, 1, 1<<20),
+		FlushInterval: s.Duration("flush_interval", 1000, time.Millisecond, 1),
+	}
+
+	s.Check(cfg.ServiceName != "", "service_name or [app] product is required")
+	param := cfg.SamplerParam
+	switch cfg.SamplerType {
+	case "const":
+		s.Check(param == 0 || param == 1, "sampler_param must be 0 or 1 for const sampler")
+	case "probabilistic":
+		s.Check(param >= 0 && param <= 1, "sampler_param must be in 0-1 for probabilistic sampler")
+	case "ratelimiting":
+		s.Check(param > 0, "sampler_param must be > 0 for ratelimiting sampler")
+	}
+	switch cfg.Reporter {
+	case "udp", "http":
+		s.Check(cfg.Endpoint != "", "endpoint is required for %s reporter", cfg.Reporter)
+	}
+	return cfg
+}
+
+//HttpClient app.ini的[http_client]
+//	timeout = 3000        每次尝试的毫秒数
+//	retry = 2             失败后的重试次数，POST、PATCH只在调用时指定Retry才重试
+//	backoff = 100         第一次重试前等待的毫秒数，之后每次翻倍
+//	max_backoff = 1000    重试等待的上限毫秒数
+//	max_idle = 32         每个host保持的空闲连接数
+type HttpClient struct {
+	Timeout    time.Duration
+	Retry      int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	MaxIdle    int
+}
+
+func loadHttpClient(s *Section) HttpClient {
+	cfg := HttpClient{
+		Timeout:    s.Duration("timeout", 3000, time.Millisecond, 1),
+		Retry:      s.IntRange("retry", 2, 0, 10),
+		Backoff:    s.Duration("backoff", 100, time.Millisecond, 0),
+		MaxBackoff: s.Duration("max_backoff", 1000, time.Millisecond, 0),
+		MaxIdle:    s.IntRange("max_idle", 32, 0, 1<<16),
+	}
+	s.Check(cfg.MaxBackoff >= cfg.Backoff, "max_backoff must be >= backoff")
+	return cfg
+}
+
+//Cache app.ini的[cache]
+//	redis = cache         redis.ini中的section
+//	prefix = gin-frame    key前缀
+//	ttl = 60              默认过期秒数
+//	negative_ttl = 10     不存在的结果缓存秒数，0为不缓存
+//	bypass_token =        内部调用方在X-Cache-Bypass请求头中带上该值时跳过缓存，为空时不允许跳过
+type Cache struct {
+	Redis       string
+	Prefix      string
+	Ttl         time.Duration
+	NegativeTtl time.Duration
+	BypassToken string
+}
+
+func loadCache(s *Section) Cache {
+	return Cache{
+		Redis:       s.String("redis", "cache"),
+		Prefix:      s.String("prefix", "gin-frame"),
+		Ttl:         s.Duration("ttl", 60, time.Second, 1),
+		NegativeTtl: s.Duration("negative_ttl", 10, time.Second, 0),
+		BypassToken: s.String("bypass_token", ""),
+	}
+}
+
+//SpyAuth app.ini的[spy_auth]
+//	store = mysql 时 conn为mysql.ini中的连接名，table为情报员表
+//	store = redis 时 conn为redis.ini中的section，key为情报员set
+//	cache_ttl = 60、negative_ttl = 10 为判定结果的缓存秒数，cache_size为缓存的情报员数
+type SpyAuth struct {
+	Store       string
+	Conn        string
+	Table       string
+	Key         string
+	CacheTtl    time.Duration
+	NegativeTtl time.Duration
+	CacheSize   int
+}
+
+func loadSpyAuth(s *Section) SpyAuth {
+	cfg := SpyAuth{
+		Store:       s.OneOf("store", "mysql", "mysql", "redis"),
+		Table:       s.String("table", "origin_spy"),
+		Key:         s.String("key", "origin::spy_customers"),
+		CacheTtl:    s.Duration("cache_ttl", 60, time.Second, 0),
+		NegativeTtl: s.Duration("negative_ttl", 10, time.Second, 0),
+		CacheSize:   s.IntRange("cache_size", 10000, 1, 1<<24),
+	}
+	if cfg.Store == "redis" {
+		cfg.Conn = s.String("conn", "default")
+	} else {
+		cfg.Conn = s.String("conn", "hangqing")
+	}
+	return cfg
+}
@@ -0,0 +1,95 @@
+package config
+
+//Config 启动时一次性加载的全部配置，各组件只读取这里的字段，不再直接读ini
+type Config struct {
+	App        App
+	Shutdown   Shutdown
+	Health     Health
+	Alert      Alert
+	Trace      Trace
+	HttpClient HttpClient
+	Cache      Cache
+	SpyAuth    SpyAuth
+	Log        Log
+
+	//Mysql 按连接名索引，如 hangqing 对应mysql.ini的[hangqing_write]和[hangqing_read]
+	Mysql map[string]*MysqlConn
+	//Redis、Amqp、Es 按section索引
+	Redis map[string]*Redis
+	Amqp  map[string]*Amqp
+	Es    map[string]*Es
+}
+
+//MysqlConn 一个连接名的读写配置
+type MysqlConn struct {
+	Write *Mysql
+	Read  *Mysql
+}
+
+//Resources 代码中固定使用的连接名和section，和app.ini中引用的一起在启动时加载校验
+type Resources struct {
+	Mysql []string
+	Redis []string
+	Amqp  []string
+	Es    []string
+}
+
+//Load 读取app.ini、log.ini以及resources和app.ini引用的mysql、redis、amqp、es配置
+//缺少必填项、格式或范围错误不会在第一个问题处停止，全部问题合并为一个error返回
+func Load(resources Resources) (*Config, error) {
+	problems := &Problems{}
+
+	cfg := &Config{
+		App:        loadApp(problems.Section("app", "app")),
+		Shutdown:   loadShutdown(problems.Section("app", "shutdown")),
+		Health:     loadHealth(problems.Section("app", "health")),
+		Alert:      loadAlert(problems.Section("app", "alert")),
+		HttpClient: loadHttpClient(problems.Section("app", "http_client")),
+		Cache:      loadCache(problems.Section("app", "cache")),
+		SpyAuth:    loadSpyAuth(problems.Section("app", "spy_auth")),
+		Log: Log{
+			Fields:  loadLogFields(problems.Section("log", "log_fields")),
+			Run:     loadLogFile(problems.Section("log", "run"), "hour"),
+			Error:   loadLogFile(problems.Section("log", "error"), "day"),
+			Request: loadRequestLog(problems.Section("log", "run")),
+		},
+		Mysql: make(map[string]*MysqlConn),
+		Redis: make(map[string]*Redis),
+		Amqp:  make(map[string]*Amqp),
+		Es:    make(map[string]*Es),
+	}
+	cfg.Trace = loadTrace(problems.Section("app", "trace"), cfg.App)
+
+	mysqlConns := append([]string{}, resources.Mysql...)
+	redisSections := append([]string{cfg.Cache.Redis}, resources.Redis...)
+	if cfg.SpyAuth.Store == "redis" {
+		redisSections = append(redisSections, cfg.SpyAuth.Conn)
+	} else {
+		mysqlConns = append(mysqlConns, cfg.SpyAuth.Conn)
+	}
+
+	for _, conn := range mysqlConns {
+		if _, ok := cfg.Mysql[conn]; !ok {
+			cfg.Mysql[conn] = &MysqlConn{
+				Write: loadMysql(problems.Section("mysql", conn+"_write")),
+				Read:  loadMysql(problems.Section("mysql", conn+"_read")),
+			}
+		}
+	}
+	for _, section := range redisSections {
+		if _, ok := cfg.Redis[section]; !ok {
+			cfg.Redis[section] = loadRedis(problems.Section("redis", section))
+		}
+	}
+	for _, section := range resources.Amqp {
+		cfg.Amqp[section] = loadAmqp(problems.Section("amqp", section))
+	}
+	for _, section := range resources.Es {
+		cfg.Es[section] = loadEs(problems.Section("es", section))
+	}
+
+	if err := problems.Err(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"time"
+)
+
+//Log log.ini
+type Log struct {
+	Fields  LogFields
+	Run     LogFile
+	Error   LogFile
+	Request RequestLog
+}
+
+//LogFields log.ini的[log_fields]，log_id和x-hop在url参数和请求头中的名字
+type LogFields struct {
+	QueryId   string
+	HeaderId  string
+	HeaderHop string
+}
+
+func loadLogFields(s *Section) LogFields {
+	return LogFields{
+		QueryId:   s.String("query_id", ""),
+		HeaderId:  s.Required("header_id"),
+		HeaderHop: s.Required("header_hop"),
+	}
+}
+
+//LogFile log.ini中[run]、[error]的文件配置
+//	dir = ./logs/run/
+//	area = 4               分片数，同一个log_id的日志固定写入同一个分片
+//	rotate = hour          hour或day
+//	max_size = 512         单个文件MB数，0为不限制
+//	max_age = 7            保留天数，0为不清理
+//	buffer = 10000         待写入日志的队列长度，队列满时丢弃并记录丢弃条数
+//	flush_interval = 1000  刷盘间隔毫秒数
+type LogFile struct {
+	Dir    string
+	Area   int
+	Rotate string
+	//MaxSize 单个文件的最大字节数，0为不限制
+	MaxSize int64
+	//MaxAge 文件保留时长，0为不清理
+	MaxAge        time.Duration
+	Buffer        int
+	FlushInterval time.Duration
+}
+
+func loadLogFile(s *Section, defaultRotate string) LogFile {
+	return LogFile{
+		Dir:           s.Required("dir"),
+		Area:          s.IntRange("area", 1, 1, 64),
+		Rotate:        s.OneOf("rotate", defaultRotate, "hour", "day"),
+		MaxSize:       int64(s.IntRange("max_size", 0, 0, 1<<20)) * 1024 * 1024,
+		MaxAge:        s.Duration("max_age", 7, 24*time.Hour, 0),
+		Buffer:        s.IntRange("buffer", 10000, 1, 1<<24),
+		FlushInterval: s.Duration("flush_interval", 1000, time.Millisecond, 1),
+	}
+}
+
+//RequestLog log.ini的[run]中请求日志的配置
+//	slow_threshold = 500   慢请求毫秒数，超过时以warn记录，0为不检查
+//	redact_headers = Authorization,Cookie,Set-Cookie,X-Cache-Bypass
+//	redact_fields = password,token
+//	redact_phone = true    隐藏手机号中间4位
+//	max_body = 4096        记录的body最大字节数，0为不记录
+//	skip_body = /upload    不记录body的路由模板，逗号分隔
+type RequestLog struct {
+	SlowThreshold time.Duration
+	RedactHeaders []string
+	RedactFields  []string
+	RedactPhone   bool
+	MaxBody       int
+	SkipBody      []string
+}
+
+func loadRequestLog(s *Section) RequestLog {
+	return RequestLog{
+		SlowThreshold: s.Duration("slow_threshold", 0, time.Millisecond, 0),
+		RedactHeaders: s.Strings("redact_headers", "Authorization,Cookie,Set-Cookie,X-Cache-Bypass"),
+		RedactFields:  s.Strings("redact_fields", "password,token"),
+		RedactPhone:   s.Bool("redact_phone", true),
+		MaxBody:       s.IntRange("max_body", 4096, 0, 1<<24),
+		SkipBody:      s.Strings("skip_body", ""),
+	}
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	go_config "github.com/why444216978/go-library/libraries/config"
+	"gopkg.in/ini.v1"
+)
+
+//Problems 收集加载过程中的全部问题，最后一次性返回
+type Problems struct {
+	list []string
+}
+
+func (p *Problems) Add(format string, args ...interface{}) {
+	p.list = append(p.list, fmt.Sprintf(format, args...))
+}
+
+//Err 没有问题时返回nil
+func (p *Problems) Err() error {
+	if len(p.list) == 0 {
+		return nil
+	}
+	return fmt.Errorf("config: %s", strings.Join(p.list, "; "))
+}
+
+//Section ini文件中的一个section，读取时的问题记录到Problems，格式为 [file section] key: 原因
+type Section struct {
+	file     string
+	name     string
+	section  *ini.Section
+	problems *Problems
+}
+
+func (p *Problems) Section(file, name string) *Section {
+	return &Section{file: file, name: name, section: go_config.GetConfig(file, name), problems: p}
+}
+
+func (s *Section) Name() string {
+	return s.name
+}
+
+//Check ok为false时记录问题
+func (s *Section) Check(ok bool, format string, args ...interface{}) {
+	if !ok {
+		s.problems.Add("[%s.ini %s] %s", s.file, s.name, fmt.Sprintf(format, args...))
+	}
+}
+
+func (s *Section) has(key string) bool {
+	return s.section.HasKey(key) && strings.TrimSpace(s.section.Key(key).String()) != ""
+}
+
+func (s *Section) String(key, def string) string {
+	if !s.has(key) {
+		return def
+	}
+	return strings.TrimSpace(s.section.Key(key).String())
+}
+
+//Required 缺少或为空时记录问题
+func (s *Section) Required(key string) string {
+	v := s.String(key, "")
+	s.Check(v != "", "%s is required", key)
+	return v
+}
+
+//OneOf 值不在values中时记录问题
+func (s *Section) OneOf(key, def string, values ...string) string {
+	v := s.String(key, def)
+	for _, value := range values {
+		if v == value {
+			return v
+		}
+	}
+	s.Check(false, "%s must be one of %s, got %q", key, strings.Join(values, ","), v)
+	return v
+}
+
+func (s *Section) Int(key string, def int) int {
+	if !s.has(key) {
+		return def
+	}
+	v, err := s.section.Key(key).Int()
+	s.Check(err == nil, "%s: %v", key, err)
+	return v
+}
+
+//IntRange 值不在[min, max]时记录问题
+func (s *Section) IntRange(key string, def, min, max int) int {
+	v := s.Int(key, def)
+	s.Check(v >= min && v <= max, "%s must be in %d-%d, got %d", key, min, max, v)
+	return v
+}
+
+func (s *Section) Float(key string, def float64) float64 {
+	if !s.has(key) {
+		return def
+	}
+	v, err := s.section.Key(key).Float64()
+	s.Check(err == nil, "%s: %v", key, err)
+	return v
+}
+
+func (s *Section) Bool(key string, def bool) bool {
+	if !s.has(key) {
+		return def
+	}
+	v, err := s.section.Key(key).Bool()
+	s.Check(err == nil, "%s: %v", key, err)
+	return v
+}
+
+//Duration 整数乘以unit，小于min时记录问题
+func (s *Section) Duration(key string, def int, unit time.Duration, min int) time.Duration {
+	v := s.Int(key, def)
+	s.Check(v >= min, "%s must be >= %d, got %d", key, min, v)
+	return time.Duration(v) * unit
+}
+
+//Strings 逗号分隔，去掉空白和空项
+func (s *Section) Strings(key, def string) []string {
+	var values []string
+	for _, v := range strings.Split(s.String(key, def), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
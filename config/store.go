@@ -0,0 +1,117 @@
+package config
+
+import (
+	"strconv"
+	"time"
+)
+
+//Mysql mysql.ini中一个section，每个连接名对应 {conn}_write 和 {conn}_read 两个section
+type Mysql struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Db       string
+	Charset  string
+	IsLog    bool
+	MaxOpen  int
+	MaxIdle  int
+}
+
+func (cfg *Mysql) DSN() string {
+	return cfg.User + ":" + cfg.Password + "@tcp(" + cfg.Host + ":" + strconv.Itoa(cfg.Port) + ")/" + cfg.Db + "?charset=" + cfg.Charset
+}
+
+func loadMysql(s *Section) *Mysql {
+	cfg := &Mysql{
+		Host:     s.Required("host"),
+		Port:     s.IntRange("port", 3306, 1, 65535),
+		User:     s.Required("user"),
+		Password: s.String("password", ""),
+		Db:       s.Required("db"),
+		Charset:  s.String("charset", "utf8"),
+		IsLog:    s.Bool("is_log", false),
+		MaxOpen:  s.IntRange("max_open", 8, 1, 10000),
+		MaxIdle:  s.IntRange("max_idle", 4, 0, 10000),
+	}
+	s.Check(cfg.MaxIdle <= cfg.MaxOpen, "max_idle must be <= max_open")
+	return cfg
+}
+
+//Redis redis.ini中一个section
+//	exec_timeout = 100000  慢命令阈值微秒数，is_log为true时记录
+//	connect_timeout、read_timeout、write_timeout为毫秒，idle_timeout为秒
+type Redis struct {
+	Host           string
+	Port           int
+	Auth           string
+	Db             int
+	MaxActive      int
+	MaxIdle        int
+	IdleTimeout    time.Duration
+	ConnectTimeout time.Duration
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+	IsLog          bool
+	//ExecTimeout 慢命令阈值，IsLog为true时超过阈值的命令记录日志
+	ExecTimeout time.Duration
+}
+
+func loadRedis(s *Section) *Redis {
+	cfg := &Redis{
+		Host:           s.Required("host"),
+		Port:           s.IntRange("port", 6379, 1, 65535),
+		Auth:           s.String("auth", ""),
+		Db:             s.IntRange("db", 0, 0, 255),
+		MaxActive:      s.IntRange("max_active", 600, 0, 100000),
+		MaxIdle:        s.IntRange("max_idle", 10, 0, 100000),
+		IdleTimeout:    s.Duration("idle_timeout", 240, time.Second, 0),
+		ConnectTimeout: s.Duration("connect_timeout", 1000, time.Millisecond, 1),
+		ReadTimeout:    s.Duration("read_timeout", 1000, time.Millisecond, 1),
+		WriteTimeout:   s.Duration("write_timeout", 1000, time.Millisecond, 1),
+		IsLog:          s.Bool("is_log", false),
+		ExecTimeout:    s.Duration("exec_timeout", 100000, time.Microsecond, 0),
+	}
+	s.Check(cfg.MaxActive == 0 || cfg.MaxIdle <= cfg.MaxActive, "max_idle must be <= max_active")
+	return cfg
+}
+
+//Amqp amqp.ini中一个section
+type Amqp struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Vhost    string
+}
+
+func (cfg *Amqp) URL() string {
+	return "amqp://" + cfg.User + ":" + cfg.Password + "@" + cfg.Host + ":" + strconv.Itoa(cfg.Port) + "/" + cfg.Vhost
+}
+
+func loadAmqp(s *Section) *Amqp {
+	return &Amqp{
+		Host:     s.Required("host"),
+		Port:     s.IntRange("port", 5672, 1, 65535),
+		User:     s.Required("user"),
+		Password: s.String("password", ""),
+		Vhost:    s.String("vhost", ""),
+	}
+}
+
+//Es es.ini中一个section，host带协议，如 http://127.0.0.1
+type Es struct {
+	Host string
+	Port int
+}
+
+func (cfg *Es) URL() string {
+	return cfg.Host + ":" + strconv.Itoa(cfg.Port)
+}
+
+func loadEs(s *Section) *Es {
+	return &Es{
+		Host: s.Required("host"),
+		Port: s.IntRange("port", 9200, 1, 65535),
+	}
+}
@@ -0,0 +1,302 @@
+package container
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+//provider 一个已注册的构造函数，按返回值类型索引
+type provider struct {
+	constructor reflect.Value
+	out         reflect.Type
+	in          []reflect.Type
+	hasErr      bool
+}
+
+//Container 类型安全的依赖注入容器
+//启动时通过Provide注册构造函数，Build一次性实例化全部依赖并检查缺失与循环依赖
+//每个类型只实例化一次，之后通过Resolve/Invoke按类型获取
+type Container struct {
+	mu        sync.RWMutex
+	providers map[reflect.Type]*provider
+	instances map[reflect.Type]reflect.Value
+	built     bool
+}
+
+func New() *Container {
+	return &Container{
+		providers: make(map[reflect.Type]*provider),
+		instances: make(map[reflect.Type]reflect.Value),
+	}
+}
+
+//Provide 注册构造函数，形如 func(deps...) T 或 func(deps...) (T, error)
+//同一类型重复注册返回错误，测试中替换实现请使用Replace
+func (c *Container) Provide(constructor interface{}) error {
+	p, err := newProvider(constructor)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.built {
+		return fmt.Errorf("container: provide %s after build", p.out)
+	}
+	if _, ok := c.providers[p.out]; ok {
+		return fmt.Errorf("container: %s already provided", p.out)
+	}
+	c.providers[p.out] = p
+	return nil
+}
+
+//Replace 覆盖已注册的构造函数，用于在测试中注入fake实现
+func (c *Container) Replace(constructor interface{}) error {
+	p, err := newProvider(constructor)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.built {
+		return fmt.Errorf("container: replace %s after build", p.out)
+	}
+	c.providers[p.out] = p
+	return nil
+}
+
+//Build 实例化所有已注册的类型
+//缺失依赖、循环依赖和构造失败会被汇总后一次性返回
+func (c *Container) Build() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []string
+	for _, t := range c.sortedTypes() {
+		if _, err := c.build(t, nil); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New("container: " + strings.Join(unique(errs), "; "))
+	}
+
+	c.built = true
+	return nil
+}
+
+//Resolve 按target指向的类型取出实例，target必须是非nil指针
+func (c *Container) Resolve(target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("container: resolve target must be a non-nil pointer, got %T", target)
+	}
+
+	instance, err := c.get(v.Elem().Type())
+	if err != nil {
+		return err
+	}
+	v.Elem().Set(instance)
+	return nil
+}
+
+//MustResolve 同Resolve，失败时panic，仅用于启动阶段
+func (c *Container) MustResolve(target interface{}) {
+	if err := c.Resolve(target); err != nil {
+		panic(err)
+	}
+}
+
+//Invoke 以容器中的实例作为参数调用fn，fn可以返回一个error
+func (c *Container) Invoke(fn interface{}) error {
+	fv := reflect.ValueOf(fn)
+	if fv.Kind() != reflect.Func {
+		return fmt.Errorf("container: invoke expects a func, got %T", fn)
+	}
+
+	ft := fv.Type()
+	args := make([]reflect.Value, ft.NumIn())
+	for i := range args {
+		arg, err := c.get(ft.In(i))
+		if err != nil {
+			return err
+		}
+		args[i] = arg
+	}
+
+	out := fv.Call(args)
+	if len(out) > 0 && ft.Out(len(out)-1) == errorType && !out[len(out)-1].IsNil() {
+		return out[len(out)-1].Interface().(error)
+	}
+	return nil
+}
+
+func (c *Container) get(t reflect.Type) (reflect.Value, error) {
+	c.mu.RLock()
+	if instance, ok := c.instances[t]; ok {
+		c.mu.RUnlock()
+		return instance, nil
+	}
+	built := c.built
+	c.mu.RUnlock()
+
+	if built {
+		//Build之后只允许读取，接口类型按实现查找
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		p, err := c.lookup(t)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return c.instances[p.out], nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.build(t, nil)
+}
+
+//build 递归构造t，path记录当前依赖链用于检测循环依赖
+func (c *Container) build(t reflect.Type, path []reflect.Type) (reflect.Value, error) {
+	if instance, ok := c.instances[t]; ok {
+		return instance, nil
+	}
+
+	p, err := c.lookup(t)
+	if err != nil {
+		return reflect.Value{}, withPath(err, path)
+	}
+	if instance, ok := c.instances[p.out]; ok {
+		c.instances[t] = instance
+		return instance, nil
+	}
+
+	for _, seen := range path {
+		if seen == p.out {
+			return reflect.Value{}, fmt.Errorf("cyclic dependency %s", formatPath(append(path, p.out)))
+		}
+	}
+	path = append(path, p.out)
+
+	args := make([]reflect.Value, len(p.in))
+	for i, in := range p.in {
+		arg, err := c.build(in, path)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		args[i] = arg
+	}
+
+	out := p.constructor.Call(args)
+	if p.hasErr && !out[1].IsNil() {
+		return reflect.Value{}, fmt.Errorf("construct %s: %v", p.out, out[1].Interface())
+	}
+
+	c.instances[p.out] = out[0]
+	if t != p.out {
+		c.instances[t] = out[0]
+	}
+	return out[0], nil
+}
+
+//lookup 查找t的构造函数
+//接口类型没有直接注册时，使用唯一一个实现了该接口的构造函数
+func (c *Container) lookup(t reflect.Type) (*provider, error) {
+	if p, ok := c.providers[t]; ok {
+		return p, nil
+	}
+
+	if t.Kind() == reflect.Interface {
+		var found []*provider
+		for _, p := range c.providers {
+			if p.out.Implements(t) {
+				found = append(found, p)
+			}
+		}
+		switch len(found) {
+		case 1:
+			return found[0], nil
+		case 0:
+		default:
+			var names []string
+			for _, p := range found {
+				names = append(names, p.out.String())
+			}
+			return nil, fmt.Errorf("ambiguous providers for %s: %s", t, strings.Join(names, ", "))
+		}
+	}
+
+	return nil, fmt.Errorf("missing provider for %s", t)
+}
+
+func (c *Container) sortedTypes() []reflect.Type {
+	types := make([]reflect.Type, 0, len(c.providers))
+	for t := range c.providers {
+		types = append(types, t)
+	}
+	//按名称排序，保证报错顺序稳定
+	for i := 1; i < len(types); i++ {
+		for j := i; j > 0 && types[j].String() < types[j-1].String(); j-- {
+			types[j], types[j-1] = types[j-1], types[j]
+		}
+	}
+	return types
+}
+
+func newProvider(constructor interface{}) (*provider, error) {
+	fv := reflect.ValueOf(constructor)
+	if fv.Kind() != reflect.Func {
+		return nil, fmt.Errorf("container: constructor must be a func, got %T", constructor)
+	}
+
+	ft := fv.Type()
+	p := &provider{constructor: fv}
+	switch {
+	case ft.NumOut() == 1 && ft.Out(0) != errorType:
+	case ft.NumOut() == 2 && ft.Out(1) == errorType:
+		p.hasErr = true
+	default:
+		return nil, fmt.Errorf("container: constructor %s must return T or (T, error)", ft)
+	}
+	p.out = ft.Out(0)
+
+	for i := 0; i < ft.NumIn(); i++ {
+		p.in = append(p.in, ft.In(i))
+	}
+	return p, nil
+}
+
+func withPath(err error, path []reflect.Type) error {
+	if len(path) == 0 {
+		return err
+	}
+	return fmt.Errorf("%v (required by %s)", err, formatPath(path))
+}
+
+func formatPath(path []reflect.Type) string {
+	names := make([]string, len(path))
+	for i, t := range path {
+		names[i] = t.String()
+	}
+	return strings.Join(names, " -> ")
+}
+
+func unique(list []string) []string {
+	seen := make(map[string]bool, len(list))
+	res := list[:0]
+	for _, v := range list {
+		if !seen[v] {
+			seen[v] = true
+			res = append(res, v)
+		}
+	}
+	return res
+}
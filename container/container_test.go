@@ -0,0 +1,148 @@
+package container
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type greeter interface {
+	Greet() string
+}
+
+type english struct{}
+
+func (english) Greet() string { return "hello" }
+
+type fake struct{}
+
+func (fake) Greet() string { return "fake" }
+
+type service struct {
+	greeter greeter
+}
+
+type a struct{}
+type b struct{}
+
+func TestResolveInterface(t *testing.T) {
+	c := New()
+	mustProvide(t, c, func() *english { return &english{} })
+	mustProvide(t, c, func(g greeter) *service { return &service{greeter: g} })
+	if err := c.Build(); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	var s *service
+	if err := c.Resolve(&s); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if s.greeter.Greet() != "hello" {
+		t.Errorf("greeter = %s, want hello", s.greeter.Greet())
+	}
+
+	var g greeter
+	if err := c.Resolve(&g); err != nil || g != s.greeter {
+		t.Errorf("Resolve(greeter) = %v, %v, want the instance injected into service", g, err)
+	}
+}
+
+func TestAmbiguousInterface(t *testing.T) {
+	c := New()
+	mustProvide(t, c, func() *english { return &english{} })
+	mustProvide(t, c, func() *fake { return &fake{} })
+	mustProvide(t, c, func(g greeter) *service { return &service{greeter: g} })
+
+	err := c.Build()
+	if err == nil || !strings.Contains(err.Error(), "ambiguous providers for container.greeter") {
+		t.Errorf("Build err = %v, want ambiguous providers", err)
+	}
+}
+
+func TestMissingProvider(t *testing.T) {
+	c := New()
+	mustProvide(t, c, func(g greeter) *service { return &service{greeter: g} })
+
+	err := c.Build()
+	if err == nil || !strings.Contains(err.Error(), "missing provider for container.greeter (required by *container.service)") {
+		t.Errorf("Build err = %v, want missing provider", err)
+	}
+
+	var s *service
+	if err := c.Resolve(&s); err == nil {
+		t.Error("Resolve succeeded without a greeter provider")
+	}
+}
+
+func TestCyclicDependency(t *testing.T) {
+	c := New()
+	mustProvide(t, c, func(*b) *a { return &a{} })
+	mustProvide(t, c, func(*a) *b { return &b{} })
+
+	err := c.Build()
+	if err == nil || !strings.Contains(err.Error(), "cyclic dependency *container.a -> *container.b -> *container.a") {
+		t.Errorf("Build err = %v, want cyclic dependency", err)
+	}
+}
+
+func TestConstructError(t *testing.T) {
+	c := New()
+	mustProvide(t, c, func() (*english, error) { return nil, errors.New("boom") })
+
+	err := c.Build()
+	if err == nil || !strings.Contains(err.Error(), "construct *container.english: boom") {
+		t.Errorf("Build err = %v, want construct error", err)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	c := New()
+	mustProvide(t, c, func() greeter { return english{} })
+	mustProvide(t, c, func(g greeter) *service { return &service{greeter: g} })
+
+	if err := c.Provide(func() greeter { return fake{} }); err == nil {
+		t.Error("Provide registered the same type twice")
+	}
+	if err := c.Replace(func() greeter { return fake{} }); err != nil {
+		t.Fatalf("Replace: %v", err)
+	}
+	if err := c.Build(); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	var s *service
+	c.MustResolve(&s)
+	if s.greeter.Greet() != "fake" {
+		t.Errorf("greeter = %s, want the replaced fake", s.greeter.Greet())
+	}
+
+	if err := c.Replace(func() greeter { return english{} }); err == nil {
+		t.Error("Replace succeeded after Build")
+	}
+}
+
+func TestSingleInstance(t *testing.T) {
+	c := New()
+	calls := 0
+	mustProvide(t, c, func() *english { calls++; return &english{} })
+	mustProvide(t, c, func(e *english) *service { return &service{greeter: e} })
+	if err := c.Build(); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	err := c.Invoke(func(e *english, s *service) {
+		if s.greeter != e {
+			t.Error("service got a different *english instance")
+		}
+	})
+	if err != nil || calls != 1 {
+		t.Errorf("Invoke err = %v, constructor calls = %d, want nil and 1", err, calls)
+	}
+}
+
+func mustProvide(t *testing.T, c *Container, constructor interface{}) {
+	t.Helper()
+	if err := c.Provide(constructor); err != nil {
+		t.Fatalf("Provide: %v", err)
+	}
+}
@@ -1,13 +1,13 @@
 package base
 
 import (
+	"gin-frame/codes"
+	"gin-frame/middlewares/request"
 	"net/http"
-	"strconv"
 	"sync"
 
 	"github.com/gin-gonic/gin"
 	"github.com/why444216978/go-library/libraries/log"
-	"github.com/why444216978/go-library/libraries/util"
 )
 
 var lock sync.RWMutex
@@ -25,25 +25,61 @@ type BaseController struct {
 
 	UserAppInfo map[string]interface{}
 
-	Code    int
-	Msg     string
-	Data    map[string]interface{}
-	UserMsg string
+	Code     int
+	Msg      string
+	Data     map[string]interface{}
+	UserMsg  string
+	HttpCode int
 }
 
 func (self *BaseController) Init(c *gin.Context, productName, moduleName string) {
 	self.C = c
 	self.XhopN = 0
 
+	//与log中间件使用同一个log_id和x-hop
 	logFormat := log.NewLog()
+	if rc := request.FromGin(c); rc != nil {
+		logFormat = rc.LogFormat(0)
+	}
 	logFormat.Product = productName
 	logFormat.Module = moduleName
 	self.LogFormat = logFormat
 	self.initResult()
 }
 
+//Validate 默认不做校验，需要校验参数的controller自行覆盖
+func (self *BaseController) Validate() bool {
+	return true
+}
+
+func (self *BaseController) Action() {}
+
+//Render 默认以json输出结果
+func (self *BaseController) Render() {
+	self.ResultJson()
+}
+
+//Fail 以err设置结果，非*codes.Error按SERVER_ERROR输出
+//原始错误记录到gin.Context.Errors，供日志中间件使用
+func (self *BaseController) Fail(err error) {
+	e := codes.FromError(err)
+	if e == nil {
+		return
+	}
+
+	self.HasError = true
+	self.Code = e.Errno
+	self.Msg = e.Msg
+	self.UserMsg = e.UserMsg
+	self.HttpCode = e.HttpStatus
+	self.Data = make(map[string]interface{})
+
+	_ = self.C.Error(err)
+}
+
 func (self *BaseController) ResultJson() {
-	self.C.JSON(http.StatusOK, gin.H{
+	self.C.Set(codes.ErrnoKey, self.Code)
+	self.C.JSON(self.HttpCode, gin.H{
 		"errno":    self.Code,
 		"errmsg":   self.Msg,
 		"data":     self.Data,
@@ -51,60 +87,29 @@ func (self *BaseController) ResultJson() {
 	})
 }
 
-func (self *BaseController) Ping() {
-	self.ResultJson()
-}
-
 func (self *BaseController) GetHeader(key string) string {
 	return self.C.Request.Header.Get(key)
 }
 
-func (self *BaseController) SetYmt() {
-	self.setCid()
-	self.setAppId()
-	self.setAppUid()
+//SetYmt 读取网关注入的用户信息，请求头格式错误时返回*codes.Error
+func (self *BaseController) SetYmt() error {
+	params := &YmtParams{}
+	if err := self.Bind(params); err != nil {
+		return err
+	}
+
+	self.Cid = params.Cid
+	self.AppUid = params.AppUid
+	self.AppId = params.AppId
+	return nil
 }
 
 func (self *BaseController) initResult() {
 	data := make(map[string]interface{})
+	self.HasError = false
 	self.Code = 0
 	self.Msg = "success"
 	self.Data = data
 	self.UserMsg = ""
-}
-
-func (self *BaseController) setCid() {
-	var cid = 0
-	res := self.GetHeader("X-Customer-Id")
-	if res != "" {
-		res, err := strconv.Atoi(res)
-		util.Must(err)
-		cid = res
-	}
-
-	self.Cid = cid
-}
-
-func (self *BaseController) setAppUid() {
-	var appUid = 0
-	res := self.GetHeader("X-User-Id")
-	if res != "" {
-		res, err := strconv.Atoi(res)
-		util.Must(err)
-		appUid = res
-	}
-
-	self.AppUid = appUid
-}
-
-func (self *BaseController) setAppId() {
-	var AppId = 0
-	res := self.GetHeader("X-User-Agent")
-	if res != "" {
-		res, err := strconv.Atoi(res)
-		util.Must(err)
-		AppId = res
-	}
-
-	self.AppId = AppId
+	self.HttpCode = http.StatusOK
 }
@@ -0,0 +1,44 @@
+package base
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+//Controller 每个请求使用一个新的controller实例，生命周期为 Init -> Bind -> Validate -> Action -> Render
+//实现了ParamsController的controller在Validate之前自动绑定参数，绑定失败时跳过Validate和Action
+//Validate返回false时跳过Action，由Validate自行调用Fail后直接Render
+type Controller interface {
+	Init(c *gin.Context, productName, moduleName string)
+	Validate() bool
+	Action()
+	Render()
+	Fail(err error)
+}
+
+//ControllerFunc 返回一个新的controller实例，依赖在注册路由时通过闭包注入
+type ControllerFunc func() Controller
+
+//Handle 把ControllerFunc包装成gin.HandlerFunc，每个请求单独实例化，避免并发请求互相覆盖C/Data等字段
+func Handle(productName, moduleName string, newController ControllerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		controller := newController()
+		controller.Init(c, productName, moduleName)
+		if bind(c, controller) && controller.Validate() {
+			controller.Action()
+		}
+		controller.Render()
+	}
+}
+
+func bind(c *gin.Context, controller Controller) bool {
+	paramsController, ok := controller.(ParamsController)
+	if !ok {
+		return true
+	}
+
+	if err := Bind(c, paramsController.Params()); err != nil {
+		controller.Fail(err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,255 @@
+package base
+
+import (
+	"encoding/json"
+	"fmt"
+	"gin-frame/codes"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+//ParamsController 声明了请求参数结构体的controller
+//Handle在Validate之前调用Bind填充Params返回的结构体，失败时直接以400输出
+//
+//结构体字段通过tag声明来源和校验规则：
+//	uri:"id"             路由参数
+//	query:"product_id"   url参数
+//	header:"X-User-Id"   请求头
+//	json:"price_list"    json请求体，只填充显式声明了json tag的字段
+//	binding:"required"   校验规则，同gin的binding tag
+//	errno:"1012"         校验失败时返回的errno，默认ERRNO_PARAMS_EMPTY
+type ParamsController interface {
+	Params() interface{}
+}
+
+//YmtParams 网关注入的用户信息请求头
+type YmtParams struct {
+	Cid    int `header:"X-Customer-Id" json:"-"`
+	AppUid int `header:"X-User-Id" json:"-"`
+	AppId  int `header:"X-User-Agent" json:"-"`
+}
+
+//Bind 按tag从请求中填充params并校验，错误统一返回*codes.Error
+func Bind(c *gin.Context, params interface{}) error {
+	v := reflect.ValueOf(params)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return codes.New(codes.SERVER_ERROR).WithMsg(fmt.Sprintf("params must be a pointer to struct, got %T", params))
+	}
+
+	if err := bindJSON(c, v.Elem()); err != nil {
+		return err
+	}
+
+	uri := make(map[string][]string, len(c.Params))
+	for _, p := range c.Params {
+		uri[p.Key] = []string{p.Value}
+	}
+	query := c.Request.URL.Query()
+	sources := map[string]func(string) []string{
+		"uri": func(key string) []string {
+			return uri[key]
+		},
+		"query": func(key string) []string {
+			return query[key]
+		},
+		"header": func(key string) []string {
+			return c.Request.Header[http.CanonicalHeaderKey(key)]
+		},
+	}
+	if err := bindFields(v.Elem(), sources); err != nil {
+		return err
+	}
+
+	return validate(v.Elem().Type(), params)
+}
+
+func (self *BaseController) Bind(params interface{}) error {
+	return Bind(self.C, params)
+}
+
+//bindJSON 解码到副本后只复制显式声明了json tag的字段
+//encoding/json会按字段名匹配没有json tag的字段，直接解码会让请求体覆盖uri、query、header参数
+func bindJSON(c *gin.Context, v reflect.Value) error {
+	if c.Request.Body == nil || c.ContentType() != binding.MIMEJSON {
+		return nil
+	}
+
+	decoded := reflect.New(v.Type())
+	decoded.Elem().Set(v)
+	err := json.NewDecoder(c.Request.Body).Decode(decoded.Interface())
+	if err != nil && err != io.EOF {
+		return codes.New(codes.ERRNO_PARAMS_EMPTY).WithMsg("invalid json body: " + err.Error()).WithCause(err)
+	}
+	copyJSONFields(v, decoded.Elem())
+	return nil
+}
+
+//copyJSONFields 把src中声明了json tag的字段复制到dst，匿名嵌入的结构体递归处理
+func copyJSONFields(dst, src reflect.Value) {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && name == "" {
+			copyJSONFields(dst.Field(i), src.Field(i))
+			continue
+		}
+		if name == "" || name == "-" || !dst.Field(i).CanSet() {
+			continue
+		}
+		dst.Field(i).Set(src.Field(i))
+	}
+}
+
+//bindFields 遍历结构体字段，按uri/query/header tag赋值，匿名嵌入的结构体递归处理
+func bindFields(v reflect.Value, sources map[string]func(string) []string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := bindFields(value, sources); err != nil {
+				return err
+			}
+			continue
+		}
+		if !value.CanSet() {
+			continue
+		}
+
+		for _, source := range []string{"uri", "query", "header"} {
+			key := field.Tag.Get(source)
+			if key == "" || key == "-" {
+				continue
+			}
+			values := sources[source](key)
+			if len(values) == 0 || values[0] == "" {
+				continue
+			}
+			if err := setValue(value, values); err != nil {
+				return codes.New(errnoOf(field)).WithMsg(fmt.Sprintf("%s %s: invalid value %q", source, key, values[0])).WithCause(err)
+			}
+		}
+	}
+	return nil
+}
+
+func setValue(value reflect.Value, values []string) error {
+	switch value.Kind() {
+	case reflect.Ptr:
+		ptr := reflect.New(value.Type().Elem())
+		if err := setValue(ptr.Elem(), values); err != nil {
+			return err
+		}
+		value.Set(ptr)
+		return nil
+	case reflect.Slice:
+		slice := reflect.MakeSlice(value.Type(), len(values), len(values))
+		for i, s := range values {
+			if err := setScalar(slice.Index(i), s); err != nil {
+				return err
+			}
+		}
+		value.Set(slice)
+		return nil
+	}
+	return setScalar(value, values[0])
+}
+
+func setScalar(value reflect.Value, s string) error {
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported kind %s", value.Kind())
+	}
+	return nil
+}
+
+//validate 使用gin的校验器检查binding tag，只返回第一个失败的字段
+func validate(t reflect.Type, params interface{}) error {
+	if binding.Validator == nil {
+		return nil
+	}
+
+	err := binding.Validator.ValidateStruct(params)
+	if err == nil {
+		return nil
+	}
+
+	errs, ok := err.(validator.ValidationErrors)
+	if !ok || len(errs) == 0 {
+		return codes.New(codes.ERRNO_PARAMS_EMPTY).WithMsg(err.Error()).WithCause(err)
+	}
+
+	fe := errs[0]
+	errno := codes.ERRNO_PARAMS_EMPTY
+	if field, ok := lookupField(t, fe.StructNamespace()); ok {
+		errno = errnoOf(field)
+	}
+	return codes.New(errno).WithMsg(fmt.Sprintf("%s failed on %s", fe.Field(), fe.Tag())).WithCause(err)
+}
+
+//lookupField 按validator的命名空间(Params.Embedded.Field)找到字段
+func lookupField(t reflect.Type, namespace string) (reflect.StructField, bool) {
+	names := strings.Split(namespace, ".")
+	var field reflect.StructField
+	for i, name := range names[1:] {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return field, false
+		}
+		f, ok := t.FieldByName(name)
+		if !ok {
+			return field, false
+		}
+		field = f
+		if i < len(names)-2 {
+			t = f.Type
+		}
+	}
+	return field, len(names) > 1
+}
+
+func errnoOf(field reflect.StructField) int {
+	errno, err := strconv.Atoi(field.Tag.Get("errno"))
+	if err != nil || errno == 0 {
+		return codes.ERRNO_PARAMS_EMPTY
+	}
+	return errno
+}
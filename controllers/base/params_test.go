@@ -0,0 +1,48 @@
+package base
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type bindTestParams struct {
+	YmtParams
+	Id      int    `uri:"id" json:"-"`
+	Cursor  string `query:"cursor"`
+	Limit   int    `query:"limit"`
+	Name    string `json:"name"`
+	Comment string `json:"comment,omitempty"`
+}
+
+func TestBindJSONOnlyTaggedFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	body := `{"Id":7,"id":7,"Cursor":"from-body","Limit":99,"Cid":1,"AppUid":2,"name":"n","comment":"c"}`
+	req := httptest.NewRequest(http.MethodPost, "/prices/3?cursor=from-query", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Customer-Id", "5")
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "3"}}
+
+	params := &bindTestParams{Limit: 20}
+	if err := Bind(c, params); err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+
+	want := bindTestParams{
+		YmtParams: YmtParams{Cid: 5},
+		Id:        3,
+		Cursor:    "from-query",
+		Limit:     20,
+		Name:      "n",
+		Comment:   "c",
+	}
+	if *params != want {
+		t.Errorf("Bind = %+v, want %+v", *params, want)
+	}
+}
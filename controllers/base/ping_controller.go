@@ -0,0 +1,6 @@
+package base
+
+//PingController 存活检查，不做任何处理直接输出errno 0
+type PingController struct {
+	BaseController
+}
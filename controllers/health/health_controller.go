@@ -0,0 +1,47 @@
+package health
+
+import (
+	"gin-frame/codes"
+	"gin-frame/controllers/base"
+	"gin-frame/health"
+	"gin-frame/shutdown"
+)
+
+//HealthController 存活检查，进程能处理请求即返回errno 0，依赖的探测结果只用于展示
+type HealthController struct {
+	base.BaseController
+
+	Checker *health.Checker
+}
+
+func (self *HealthController) Action() {
+	report := self.Checker.Run(self.C.Request.Context())
+	self.Data["status"] = report.Status
+	self.Data["checks"] = report.Results
+}
+
+//ReadyController 就绪检查，任意依赖不可用或进程正在退出时返回503
+type ReadyController struct {
+	base.BaseController
+
+	Checker     *health.Checker
+	Coordinator *shutdown.Coordinator
+}
+
+func (self *ReadyController) Action() {
+	//endless重启或退出期间不再探测依赖，连接池可能已经关闭
+	if self.Coordinator.Draining() {
+		self.Fail(codes.New(codes.ERRNO_NOT_READY).WithMsg("draining"))
+		self.Data["status"] = health.StatusDown
+		self.Data["draining"] = true
+		return
+	}
+
+	report := self.Checker.Run(self.C.Request.Context())
+	if !report.Up() {
+		self.Fail(codes.New(codes.ERRNO_NOT_READY))
+	}
+	self.Data["status"] = report.Status
+	self.Data["draining"] = false
+	self.Data["checks"] = report.Results
+}
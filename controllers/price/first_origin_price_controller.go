@@ -1,74 +1,93 @@
 package price
 
 import (
+	"crypto/subtle"
 	"gin-frame/controllers/base"
-	"gin-frame/service"
+	"gin-frame/library/location"
+	"gin-frame/library/product"
+	"gin-frame/models/hangqing/origin_price_model"
 	"gin-frame/service/origin_price_service"
+	"gin-frame/views/origin_price_view"
 	"sync"
-
-	"github.com/gin-gonic/gin"
 )
 
+//CacheBypassHeader 内部调用方跳过缓存的请求头，值为app.ini中[cache]的bypass_token
+const CacheBypassHeader = "X-Cache-Bypass"
+
 type FirstOriginPriceController struct {
 	base.BaseController
 	OriginPriceService *origin_price_service.OriginPriceService
-	Result             map[string]interface{}
-}
+	//BypassToken 为空时不允许跳过缓存
+	BypassToken string
 
-func (self *FirstOriginPriceController) Init(c *gin.Context, productName, moduleName string) {
-	self.BaseController.Init(c, productName, moduleName)
-	self.BaseController.SetYmt()
-	var data = make(map[string]interface{})
-	self.Result = data
+	origin   *origin_price_model.OriginPrice
+	product  *product.ProductDetail
+	location *location.LocationDetail
 }
 
-func (self *FirstOriginPriceController) Do() {
-	self.load()
-	self.action()
-	self.setData()
-	self.ResultJson()
+func (self *FirstOriginPriceController) Validate() bool {
+	if err := self.SetYmt(); err != nil {
+		self.Fail(err)
+		return false
+	}
+	return true
 }
 
-func (self *FirstOriginPriceController) load() {
-	serviceFactory := &service.ServiceFactory{}
-	originPriceFactory := serviceFactory.GetInstance("OriginPriceService")
-	self.OriginPriceService = originPriceFactory["OriginPriceService"].(*origin_price_service.OriginPriceService)
+func (self *FirstOriginPriceController) Action() {
+	if !self.action() {
+		return
+	}
+	self.setData()
 }
 
-func (self *FirstOriginPriceController) action() {
-	origin := self.OriginPriceService.GetFirstRow(true)
-	self.Data["origin"] = origin
-
-	productId := 0
-	locationId := 0
-
-	if origin != nil {
-		if origin["product_id"] != nil {
-			productId = origin["product_id"].(int)
-			if origin["breed_id"] != nil {
-				productId = origin["breed_id"].(int)
-			}
-		}
+func (self *FirstOriginPriceController) action() bool {
+	origin, err := self.OriginPriceService.GetFirstRow(self.C.Request.Context(), self.noCache())
+	if err != nil {
+		self.Fail(err)
+		return false
+	}
+	self.origin = origin
 
-		if origin["location_id"] != nil {
-			locationId = origin["location_id"].(int)
-		}
+	if origin == nil {
+		return true
 	}
 
+	var productErr, locationErr error
 	var wg sync.WaitGroup
 	wg.Add(2)
 	go func() {
 		defer wg.Done()
-		self.Data["product"] = self.OriginPriceService.GetOriginPriceProduct(self.C, productId)
+		self.product, productErr = self.OriginPriceService.GetOriginPriceProduct(self.C.Request.Context(), origin.DetailProductId())
 	}()
 
 	go func() {
 		defer wg.Done()
-		self.Data["location"] = self.OriginPriceService.GetOriginPriceLocation(self.C, locationId)
+		self.location, locationErr = self.OriginPriceService.GetOriginPriceLocation(self.C.Request.Context(), origin.Location_id)
 	}()
 	wg.Wait()
+
+	if productErr != nil {
+		self.Fail(productErr)
+		return false
+	}
+	if locationErr != nil {
+		self.Fail(locationErr)
+		return false
+	}
+	return true
 }
 
-func (self *FirstOriginPriceController) setData() {
+//noCache 只有带上正确bypass_token的内部调用才跳过缓存，外部请求无法绕过缓存直接压到数据库
+func (self *FirstOriginPriceController) noCache() bool {
+	token := self.C.GetHeader(CacheBypassHeader)
+	if self.BypassToken == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(self.BypassToken)) == 1
+}
 
+func (self *FirstOriginPriceController) setData() {
+	self.Data["origin"] = origin_price_view.NewOriginPriceView(self.origin)
+	self.Data["product"] = origin_price_view.NewProductView(self.product)
+	self.Data["location"] = origin_price_view.NewLocationView(self.location)
 }
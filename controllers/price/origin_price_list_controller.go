@@ -0,0 +1,117 @@
+package price
+
+import (
+	"gin-frame/controllers/base"
+	"gin-frame/library/logger"
+	"gin-frame/models/hangqing/origin_price_model"
+	"gin-frame/service/origin_price_service"
+	"gin-frame/views/origin_price_view"
+)
+
+type originPriceListParams struct {
+	CustomerId int    `query:"customer_id" binding:"omitempty,min=1"`
+	ProductId  int    `query:"product_id" binding:"omitempty,min=1"`
+	BreedId    int    `query:"breed_id" binding:"omitempty,min=1" errno:"1009"`
+	ProvinceId int    `query:"province_id" binding:"omitempty,min=1"`
+	CityId     int    `query:"city_id" binding:"omitempty,min=1"`
+	CountyId   int    `query:"county_id" binding:"omitempty,min=1"`
+	LocationId int    `query:"location_id" binding:"omitempty,min=1"`
+	StartDay   string `query:"start_day" binding:"omitempty,datetime=2006-01-02"`
+	EndDay     string `query:"end_day" binding:"omitempty,datetime=2006-01-02"`
+	Status     *int   `query:"status"`
+	OrderBy    string `query:"order_by" binding:"omitempty,oneof=id day_time"`
+	Sort       string `query:"sort" binding:"omitempty,oneof=asc desc"`
+	Limit      int    `query:"limit" binding:"omitempty,min=1,max=100"`
+	Offset     int    `query:"offset" binding:"omitempty,min=0"`
+	Cursor     string `query:"cursor"`
+}
+
+//OriginPriceListController 报价列表，支持offset和cursor两种分页方式
+type OriginPriceListController struct {
+	base.BaseController
+	OriginPriceService *origin_price_service.OriginPriceService
+	//Logger 记录缓存中不存在的品类和地区
+	Logger *logger.Logger
+
+	params     originPriceListParams
+	page       *origin_price_service.OriginPricePage
+	enrichment *origin_price_service.Enrichment
+}
+
+func (self *OriginPriceListController) Params() interface{} {
+	return &self.params
+}
+
+func (self *OriginPriceListController) Validate() bool {
+	if err := self.SetYmt(); err != nil {
+		self.Fail(err)
+		return false
+	}
+	return true
+}
+
+func (self *OriginPriceListController) Action() {
+	page, err := self.OriginPriceService.ListOriginPrices(self.C.Request.Context(), self.query(), self.params.Cursor)
+	if err != nil {
+		self.Fail(err)
+		return
+	}
+	self.page = page
+
+	loaders := self.OriginPriceService.NewLoaders()
+	enrichment, err := self.OriginPriceService.EnrichOriginPrices(self.C.Request.Context(), loaders, page.List)
+	if err != nil {
+		self.Fail(err)
+		return
+	}
+	self.enrichment = enrichment
+	self.logMissing()
+	self.setData()
+}
+
+//logMissing 品类或地区缓存缺失时列表仍然返回，对应字段为空，记录告警便于补数据
+func (self *OriginPriceListController) logMissing() {
+	if self.Logger == nil || (len(self.enrichment.MissingProducts) == 0 && len(self.enrichment.MissingLocations) == 0) {
+		return
+	}
+	self.Logger.Warn(self.LogFormat, map[string]interface{}{
+		"msg":               "origin price enrichment missing",
+		"missing_products":  self.enrichment.MissingProducts,
+		"missing_locations": self.enrichment.MissingLocations,
+	})
+}
+
+func (self *OriginPriceListController) query() *origin_price_model.OriginPriceQuery {
+	p := self.params
+	return &origin_price_model.OriginPriceQuery{
+		CustomerId:   p.CustomerId,
+		ProductId:    p.ProductId,
+		BreedId:      p.BreedId,
+		ProvinceId:   p.ProvinceId,
+		CityId:       p.CityId,
+		CountyId:     p.CountyId,
+		LocationId:   p.LocationId,
+		DayTimeStart: p.StartDay,
+		DayTimeEnd:   p.EndDay,
+		Status:       p.Status,
+		OrderBy:      p.OrderBy,
+		Asc:          p.Sort == "asc",
+		Limit:        p.Limit,
+		Offset:       p.Offset,
+	}
+}
+
+func (self *OriginPriceListController) setData() {
+	list := make([]*origin_price_view.OriginPriceItemView, 0, len(self.page.List))
+	for i := range self.page.List {
+		origin := &self.page.List[i]
+		list = append(list, origin_price_view.NewOriginPriceItemView(origin,
+			self.enrichment.Products[origin.DetailProductId()],
+			self.enrichment.Locations[origin.Location_id]))
+	}
+
+	self.Data["list"] = list
+	self.Data["has_more"] = self.page.HasMore
+	self.Data["next_cursor"] = self.page.NextCursor
+	self.Data["total"] = self.page.Total
+}
@@ -0,0 +1,118 @@
+package price
+
+import (
+	"gin-frame/codes"
+	"gin-frame/controllers/base"
+	"gin-frame/middlewares/auth"
+	"gin-frame/models/hangqing/origin_price_model"
+	"gin-frame/service/origin_price_service"
+	"gin-frame/views/origin_price_view"
+)
+
+type originPriceCreateParams struct {
+	ProductId  int    `json:"product_id" binding:"required,min=1" errno:"1012"`
+	BreedId    int    `json:"breed_id" binding:"required,min=1" errno:"1010"`
+	ProvinceId int    `json:"province_id" binding:"omitempty,min=1"`
+	CityId     int    `json:"city_id" binding:"omitempty,min=1"`
+	CountyId   int    `json:"county_id" binding:"omitempty,min=1"`
+	LocationId int    `json:"location_id" binding:"required,min=1"`
+	PointKey   string `json:"point_key"`
+	DayTime    string `json:"day_time" binding:"omitempty,datetime=2006-01-02" errno:"1014"`
+	PriceList  string `json:"price_list" binding:"required" errno:"1003"`
+	DescList   string `json:"desc_list" binding:"required" errno:"1004"`
+}
+
+type originPriceUpdateParams struct {
+	Id        int    `uri:"id" json:"-" binding:"required,min=1" errno:"1002"`
+	BreedId   int    `json:"breed_id" binding:"required,min=1" errno:"1010"`
+	PriceList string `json:"price_list" binding:"required" errno:"1003"`
+	DescList  string `json:"desc_list" binding:"required" errno:"1004"`
+}
+
+//OriginPriceCreateController 情报员新增报价
+type OriginPriceCreateController struct {
+	base.BaseController
+	OriginPriceService *origin_price_service.OriginPriceService
+
+	params originPriceCreateParams
+	origin *origin_price_model.OriginPrice
+}
+
+func (self *OriginPriceCreateController) Params() interface{} {
+	return &self.params
+}
+
+func (self *OriginPriceCreateController) Validate() bool {
+	return validateCustomer(&self.BaseController)
+}
+
+func (self *OriginPriceCreateController) Action() {
+	p := self.params
+	origin, err := self.OriginPriceService.CreateOriginPrice(self.C.Request.Context(), &origin_price_service.OriginPriceInput{
+		CustomerId: self.Cid,
+		ProductId:  p.ProductId,
+		BreedId:    p.BreedId,
+		ProvinceId: p.ProvinceId,
+		CityId:     p.CityId,
+		CountyId:   p.CountyId,
+		LocationId: p.LocationId,
+		PointKey:   p.PointKey,
+		DayTime:    p.DayTime,
+		PriceList:  p.PriceList,
+		DescList:   p.DescList,
+	})
+	if err != nil {
+		self.Fail(err)
+		return
+	}
+	self.origin = origin
+	self.Data["origin"] = origin_price_view.NewOriginPriceView(self.origin)
+}
+
+//OriginPriceUpdateController 情报员修改最新一条报价
+type OriginPriceUpdateController struct {
+	base.BaseController
+	OriginPriceService *origin_price_service.OriginPriceService
+
+	params originPriceUpdateParams
+	origin *origin_price_model.OriginPrice
+}
+
+func (self *OriginPriceUpdateController) Params() interface{} {
+	return &self.params
+}
+
+func (self *OriginPriceUpdateController) Validate() bool {
+	return validateCustomer(&self.BaseController)
+}
+
+func (self *OriginPriceUpdateController) Action() {
+	p := self.params
+	origin, err := self.OriginPriceService.UpdateOriginPrice(self.C.Request.Context(), p.Id, &origin_price_service.OriginPriceInput{
+		CustomerId: self.Cid,
+		BreedId:    p.BreedId,
+		PriceList:  p.PriceList,
+		DescList:   p.DescList,
+	})
+	if err != nil {
+		self.Fail(err)
+		return
+	}
+	self.origin = origin
+	self.Data["origin"] = origin_price_view.NewOriginPriceView(self.origin)
+}
+
+//validateCustomer 写接口使用SpyAuth鉴权通过的情报员ID，不再重新解析请求头，保证鉴权和写入的是同一个ID
+func validateCustomer(controller *base.BaseController) bool {
+	if err := controller.SetYmt(); err != nil {
+		controller.Fail(err)
+		return false
+	}
+	customerId := controller.C.GetInt(auth.CustomerIdKey)
+	if customerId == 0 {
+		controller.Fail(codes.New(codes.ERRNO_MISS_ORIGIN_CUSTOMER_ID))
+		return false
+	}
+	controller.Cid = customerId
+	return true
+}
@@ -1,40 +1,70 @@
 package origin_price_dao
 
 import (
+	"context"
+	"gin-frame/cache"
+	"gin-frame/models/base"
 	"gin-frame/models/hangqing/origin_price_model"
 	"log"
-	"sync"
+	"time"
+)
 
-	"github.com/why444216978/go-library/libraries/util/conversion"
+const (
+	cacheNamespace = "origin_price"
+	cacheTtl       = 5 * time.Minute
+	firstRowKey    = "first"
 )
 
 type OriginPriceDao struct {
 	originPriceModel *origin_price_model.OriginPriceModel
+	cache            *cache.Namespace
 }
 
-var onceOriginPriceDao sync.Once
-var originPriceDao *OriginPriceDao
+func NewOriginPriceDao(originPriceModel *origin_price_model.OriginPriceModel, c *cache.Cache) *OriginPriceDao {
+	originPriceDao := &OriginPriceDao{}
+	originPriceDao.originPriceModel = originPriceModel
+	originPriceDao.cache = c.Namespace(cacheNamespace, cacheTtl, base.ErrNotFound)
+	log.Printf("new origin_price_dao")
+
+	return originPriceDao
+}
 
-func NewObj() *OriginPriceDao {
-	onceOriginPriceDao.Do(func() {
-		originPriceDao = &OriginPriceDao{}
-		originPriceDao.originPriceModel = origin_price_model.NewOriginPriceModel()
-		log.Printf("new origin_price_dao")
+//GetFirstRow noCache为true时跳过缓存直接查库
+func (self *OriginPriceDao) GetFirstRow(ctx context.Context, noCache bool) (*origin_price_model.OriginPrice, error) {
+	originPrice := &origin_price_model.OriginPrice{}
+	err := self.cache.Get(ctx, firstRowKey, originPrice, noCache, func(ctx context.Context) (interface{}, error) {
+		return self.originPriceModel.GetFirst(ctx)
 	})
+	if err != nil {
+		return nil, err
+	}
+	return originPrice, nil
+}
 
-	return originPriceDao
+func (self *OriginPriceDao) GetList(ctx context.Context, query *origin_price_model.OriginPriceQuery) ([]origin_price_model.OriginPrice, error) {
+	return self.originPriceModel.List(ctx, query)
+}
+
+func (self *OriginPriceDao) GetCount(ctx context.Context, query *origin_price_model.OriginPriceQuery) (int, error) {
+	return self.originPriceModel.Count(ctx, query)
 }
 
-func (self *OriginPriceDao) GetFirstRow(noCache bool) map[string]interface{} {
-	dbRes := self.originPriceModel.GetFirst()
+func (self *OriginPriceDao) GetMasterRow(ctx context.Context, id int) (*origin_price_model.OriginPrice, error) {
+	return self.originPriceModel.GetMasterById(ctx, id)
+}
 
-	result := make(map[string]interface{})
-	if dbRes != nil {
-		for _, v := range dbRes {
-			result = conversion.StructToMap(v)
-			break
-		}
+//WithCustomerLock 同一情报员的写操作串行执行，fn成功提交后删除缓存
+func (self *OriginPriceDao) WithCustomerLock(ctx context.Context, customerId int, fn func(tx origin_price_model.CustomerTx) error) error {
+	if err := self.originPriceModel.WithCustomerLock(ctx, customerId, fn); err != nil {
+		return err
 	}
+	self.invalidate(ctx)
+	return nil
+}
 
-	return result
+//invalidate 写入成功后删除缓存，删除失败只记录日志，等待过期
+func (self *OriginPriceDao) invalidate(ctx context.Context) {
+	if err := self.cache.Delete(ctx, firstRowKey); err != nil {
+		log.Printf("origin_price_dao invalidate: %v", err)
+	}
 }
@@ -0,0 +1,123 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+//Check 探测一个依赖，返回nil表示可用，ctx到期后应尽快返回
+type Check func(ctx context.Context) error
+
+type check struct {
+	name string
+	fn   Check
+}
+
+//Result 单个依赖的探测结果
+type Result struct {
+	Name    string  `json:"name"`
+	Status  string  `json:"status"`
+	Latency float64 `json:"latency_ms"`
+	Error   string  `json:"error,omitempty"`
+}
+
+//Report 全部依赖的探测结果，Results按注册顺序排列
+type Report struct {
+	Status  string   `json:"status"`
+	Results []Result `json:"checks"`
+}
+
+//Up 全部依赖可用
+func (r *Report) Up() bool {
+	return r.Status == StatusUp
+}
+
+//Checker 管理依赖探测，每次Run并发执行全部Check，单个Check超过timeout视为不可用
+type Checker struct {
+	timeout time.Duration
+
+	lock   sync.RWMutex
+	checks []check
+}
+
+func New(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+//Register 注册依赖探测，name在报告中展示，如mysql:hangqing_write、redis:location
+func (checker *Checker) Register(name string, fn Check) {
+	checker.lock.Lock()
+	defer checker.lock.Unlock()
+
+	checker.checks = append(checker.checks, check{name: name, fn: fn})
+}
+
+//Run 执行全部Check，任意一个不可用时Report.Status为down
+func (checker *Checker) Run(ctx context.Context) *Report {
+	checker.lock.RLock()
+	checks := make([]check, len(checker.checks))
+	copy(checks, checker.checks)
+	checker.lock.RUnlock()
+
+	report := &Report{Status: StatusUp, Results: make([]Result, len(checks))}
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c check) {
+			defer wg.Done()
+			report.Results[i] = checker.run(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	for _, result := range report.Results {
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+			break
+		}
+	}
+	return report
+}
+
+//run 底层客户端不一定响应ctx，超时后不再等待Check返回
+func (checker *Checker) run(ctx context.Context, c check) Result {
+	ctx, cancel := context.WithTimeout(ctx, checker.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- c.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timeout after %s", checker.timeout)
+	}
+
+	result := Result{
+		Name:    c.name,
+		Status:  StatusUp,
+		Latency: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,238 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gin-frame/config"
+)
+
+//Alert 一次告警的内容
+type Alert struct {
+	Title     string    `json:"title"`
+	Product   string    `json:"product"`
+	Module    string    `json:"module"`
+	Env       string    `json:"env"`
+	LogId     string    `json:"log_id"`
+	Method    string    `json:"method"`
+	UriPath   string    `json:"uri_path"`
+	PanicType string    `json:"panic_type"`
+	Message   string    `json:"message"`
+	Signature string    `json:"signature"`
+	Stack     string    `json:"stack"`
+	Time      time.Time `json:"time"`
+}
+
+//Sink 告警的发送目标，Send在Notifier的发送协程中串行调用
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, alert *Alert) error
+}
+
+//WebhookSink 以json POST到url，非2xx视为失败
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookSink(url string, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (sink *WebhookSink) Name() string {
+	return "webhook"
+}
+
+func (sink *WebhookSink) Send(ctx context.Context, alert *Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, sink.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := sink.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+//FileSink 每条告警以一行json追加到文件
+type FileSink struct {
+	path string
+}
+
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+func (sink *FileSink) Name() string {
+	return "file"
+}
+
+func (sink *FileSink) Send(ctx context.Context, alert *Alert) error {
+	line, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(sink.path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(sink.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+//LocalSink 输出到标准日志，本地开发和没有告警通道的环境使用
+type LocalSink struct{}
+
+func (sink *LocalSink) Name() string {
+	return "local"
+}
+
+func (sink *LocalSink) Send(ctx context.Context, alert *Alert) error {
+	log.Printf("[alert] %s %s %s log_id=%s signature=%s: %s", alert.Title, alert.Method, alert.UriPath, alert.LogId, alert.Signature, alert.Message)
+	return nil
+}
+
+//Notifier 异步发送告警，按每分钟rate条限流，队列满或超过限流的告警丢弃并计数
+type Notifier struct {
+	sinks   []Sink
+	timeout time.Duration
+
+	lock    sync.Mutex
+	rate    float64
+	tokens  float64
+	last    time.Time
+	closed  bool
+	alerts  chan *Alert
+	done    chan struct{}
+	dropped int64
+}
+
+//New ratePerMinute<=0时不限流，timeout为每个sink发送一条告警的最长时间
+func New(sinks []Sink, ratePerMinute int, timeout time.Duration) *Notifier {
+	n := &Notifier{
+		sinks:   sinks,
+		timeout: timeout,
+		rate:    float64(ratePerMinute),
+		tokens:  float64(ratePerMinute),
+		last:    time.Now(),
+		alerts:  make(chan *Alert, 100),
+		done:    make(chan struct{}),
+	}
+	go n.run()
+	return n
+}
+
+//NewNotifier 按app.ini的[alert]创建，sink名称已在加载配置时校验
+func NewNotifier(cfg *config.Config) *Notifier {
+	alertCfg := cfg.Alert
+
+	var sinks []Sink
+	for _, name := range alertCfg.Sinks {
+		switch name {
+		case "local":
+			sinks = append(sinks, &LocalSink{})
+		case "file":
+			sinks = append(sinks, NewFileSink(alertCfg.File))
+		case "webhook":
+			sinks = append(sinks, NewWebhookSink(alertCfg.Webhook, alertCfg.Timeout))
+		}
+	}
+
+	return New(sinks, alertCfg.Rate, alertCfg.Timeout)
+}
+
+//Notify 不阻塞调用方，返回false表示被限流或丢弃
+func (n *Notifier) Notify(alert *Alert) bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.closed || !n.allow(time.Now()) {
+		atomic.AddInt64(&n.dropped, 1)
+		return false
+	}
+	select {
+	case n.alerts <- alert:
+		return true
+	default:
+		atomic.AddInt64(&n.dropped, 1)
+		return false
+	}
+}
+
+//Dropped 被限流或丢弃的告警数
+func (n *Notifier) Dropped() int64 {
+	return atomic.LoadInt64(&n.dropped)
+}
+
+//allow 令牌桶，容量为每分钟的rate，调用方需持有锁
+func (n *Notifier) allow(now time.Time) bool {
+	if n.rate <= 0 {
+		return true
+	}
+	n.tokens += now.Sub(n.last).Minutes() * n.rate
+	if n.tokens > n.rate {
+		n.tokens = n.rate
+	}
+	n.last = now
+	if n.tokens < 1 {
+		return false
+	}
+	n.tokens--
+	return true
+}
+
+func (n *Notifier) run() {
+	defer close(n.done)
+	for alert := range n.alerts {
+		for _, sink := range n.sinks {
+			ctx, cancel := context.WithTimeout(context.Background(), n.timeout)
+			if err := sink.Send(ctx, alert); err != nil {
+				log.Printf("send alert to %s: %v", sink.Name(), err)
+			}
+			cancel()
+		}
+	}
+}
+
+//Close 停止接收告警，等待队列中的告警发送完成
+func (n *Notifier) Close(ctx context.Context) error {
+	n.lock.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.alerts)
+	}
+	n.lock.Unlock()
+
+	select {
+	case <-n.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("flush alerts: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,255 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"gin-frame/config"
+	"gin-frame/library/logger"
+	"gin-frame/library/tracer"
+	"gin-frame/middlewares/request"
+
+	go_log "github.com/why444216978/go-library/libraries/log"
+	"github.com/why444216978/go-library/libraries/xhop"
+)
+
+//Request 一次出站调用，Timeout为0时使用[http_client]的timeout
+//Retry为0时GET、HEAD、PUT、DELETE、OPTIONS使用[http_client]的retry，其他方法不重试；小于0时不重试
+type Request struct {
+	Method  string
+	URL     string
+	Header  http.Header
+	Body    []byte
+	Timeout time.Duration
+	Retry   int
+}
+
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+//Client 出站http调用，自动传播trace、log_id和x-hop，并把每次调用写入运行日志
+type Client struct {
+	config *config.HttpClient
+	fields config.LogFields
+	client *http.Client
+	logger *logger.Logger
+}
+
+//NewClient 按app.ini的[http_client]创建，log_id和x-hop的请求头取自log.ini的[log_fields]，调用日志写入[run]
+func NewClient(cfg *config.Config, loggers *logger.Loggers) *Client {
+	return New(&cfg.HttpClient, cfg.Log.Fields, loggers.Run)
+}
+
+func New(cfg *config.HttpClient, fields config.LogFields, runLogger *logger.Logger) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = cfg.MaxIdle
+
+	return &Client{
+		config: cfg,
+		fields: fields,
+		client: &http.Client{Transport: tracer.NewTransport(transport)},
+		logger: runLogger,
+	}
+}
+
+//Do 502、503、504、429和网络错误按指数退避重试，500只在幂等方法时重试，返回最后一次的响应，非2xx不视为error
+func (client *Client) Do(ctx context.Context, req *Request) (*Response, error) {
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = client.config.Timeout
+	}
+	retry := req.Retry
+	if retry == 0 && idempotent(method) {
+		retry = client.config.Retry
+	}
+
+	header := client.propagate(ctx, req.Header)
+
+	start := time.Now()
+	var resp *Response
+	var err error
+	attempts := 0
+	for {
+		attempts++
+		resp, err = client.attempt(ctx, method, req.URL, header, req.Body, timeout)
+		if attempts > retry || !retryable(ctx, method, resp, err) {
+			break
+		}
+		if waitErr := client.wait(ctx, attempts); waitErr != nil {
+			break
+		}
+	}
+
+	client.log(ctx, method, req.URL, resp, err, attempts, time.Since(start))
+	if err != nil {
+		return nil, fmt.Errorf("http %s %s: %w", method, req.URL, err)
+	}
+	return resp, nil
+}
+
+func (client *Client) Get(ctx context.Context, url string, header http.Header) (*Response, error) {
+	return client.Do(ctx, &Request{Method: http.MethodGet, URL: url, Header: header})
+}
+
+func (client *Client) Post(ctx context.Context, url, contentType string, body []byte) (*Response, error) {
+	header := http.Header{}
+	header.Set("Content-Type", contentType)
+	return client.Do(ctx, &Request{Method: http.MethodPost, URL: url, Header: header, Body: body})
+}
+
+func (client *Client) attempt(ctx context.Context, method, url string, header http.Header, body []byte, timeout time.Duration) (*Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	httpReq, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq = httpReq.WithContext(ctx)
+	httpReq.Header = header.Clone()
+
+	httpResp, err := client.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	//在cancel之前读完body，否则读取会因ctx取消而失败
+	data, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{StatusCode: httpResp.StatusCode, Header: httpResp.Header, Body: data}, nil
+}
+
+//propagate 复制调用方的header，加上当前请求的log_id和下一跳x-hop
+//不在请求中的调用(如定时任务)生成新的log_id
+func (client *Client) propagate(ctx context.Context, header http.Header) http.Header {
+	out := header.Clone()
+	if out == nil {
+		out = http.Header{}
+	}
+
+	logID := ""
+	var hop *xhop.XHop
+	if rc := request.FromContext(ctx); rc != nil {
+		logID = rc.LogId
+		hop = rc.XHop
+	}
+	if logID == "" {
+		logID = go_log.NewObjectId().Hex()
+	}
+	if client.fields.HeaderId != "" {
+		out.Set(client.fields.HeaderId, logID)
+	}
+	if client.fields.HeaderHop != "" {
+		current := http.Header{}
+		if hop != nil {
+			current.Set(client.fields.HeaderHop, hop.String())
+		}
+		out.Set(client.fields.HeaderHop, xhop.NextXhop(current, client.fields.HeaderHop).String())
+	}
+	return out
+}
+
+//wait 第attempts次失败后的退避，加入一半的随机抖动避免多个实例同时重试
+func (client *Client) wait(ctx context.Context, attempts int) error {
+	backoff := client.config.Backoff << uint(attempts-1)
+	if backoff > client.config.MaxBackoff || backoff <= 0 {
+		backoff = client.config.MaxBackoff
+	}
+	if backoff <= 0 {
+		return ctx.Err()
+	}
+	backoff = backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+//log 日志头沿用入站请求的LogFormat，HttpCode为出站调用的状态码
+func (client *Client) log(ctx context.Context, method, url string, resp *Response, err error, attempts int, latency time.Duration) {
+	if client.logger == nil {
+		return
+	}
+
+	var header *go_log.LogFormat
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode
+	}
+	if rc := request.FromContext(ctx); rc != nil {
+		header = rc.LogFormat(statusCode)
+	}
+
+	//url的query可能带有token等参数，只记录到path
+	if i := strings.IndexByte(url, '?'); i >= 0 {
+		url = url[:i]
+	}
+	fields := map[string]interface{}{
+		"msg":         "http client",
+		"call_method": method,
+		"call_url":    url,
+		"call_status": statusCode,
+		"attempts":    attempts,
+		"latency_ms":  float64(latency.Microseconds()) / 1000,
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+
+	switch {
+	case err != nil || statusCode >= http.StatusInternalServerError:
+		client.logger.Error(header, fields)
+	case statusCode >= http.StatusBadRequest || attempts > 1:
+		client.logger.Warn(header, fields)
+	default:
+		client.logger.Info(header, fields)
+	}
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+//retryable 调用方ctx已结束时不再重试
+//500可能是请求已经部分执行后失败，非幂等方法重试会重复写入
+func retryable(ctx context.Context, method string, resp *Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case http.StatusInternalServerError:
+		return idempotent(method)
+	}
+	return false
+}
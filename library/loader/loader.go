@@ -0,0 +1,179 @@
+package loader
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWait     = 2 * time.Millisecond
+	defaultMaxBatch = 100
+)
+
+//BatchFunc 一次查询多个ID，返回结果中不存在的ID视为缺失
+type BatchFunc func(ctx context.Context, ids []int) (map[int]interface{}, error)
+
+type result struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+//Loader 请求级别的批量加载器
+//同一请求内wait时间窗口内发起的Load会合并为一次BatchFunc调用，结果在请求内缓存
+//Loader不是全局共享的，每个请求创建一个新的实例
+type Loader struct {
+	batch    BatchFunc
+	wait     time.Duration
+	maxBatch int
+
+	lock     sync.Mutex
+	results  map[int]*result
+	pending  []int
+	batchCtx context.Context
+	timer    *time.Timer
+	missing  map[int]bool
+}
+
+//New wait<=0或maxBatch<=0时使用默认值
+func New(batch BatchFunc, wait time.Duration, maxBatch int) *Loader {
+	if wait <= 0 {
+		wait = defaultWait
+	}
+	if maxBatch <= 0 {
+		maxBatch = defaultMaxBatch
+	}
+	return &Loader{
+		batch:    batch,
+		wait:     wait,
+		maxBatch: maxBatch,
+		results:  make(map[int]*result),
+		missing:  make(map[int]bool),
+	}
+}
+
+//Load 加载单个ID，ID不存在时返回nil
+func (l *Loader) Load(ctx context.Context, id int) (interface{}, error) {
+	l.lock.Lock()
+	r := l.enqueue(ctx, id)
+	l.lock.Unlock()
+
+	return wait(ctx, r)
+}
+
+//LoadMany 加载多个ID，返回结果中不包含不存在的ID
+//调用方已经拿到全部ID，不再等待时间窗口，立即发起查询
+func (l *Loader) LoadMany(ctx context.Context, ids []int) (map[int]interface{}, error) {
+	l.lock.Lock()
+	results := make(map[int]*result, len(ids))
+	for _, id := range ids {
+		results[id] = l.enqueue(ctx, id)
+	}
+	l.flush()
+	l.lock.Unlock()
+
+	values := make(map[int]interface{}, len(ids))
+	for id, r := range results {
+		value, err := wait(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			values[id] = value
+		}
+	}
+	return values, nil
+}
+
+//Missing 本请求中查询过但不存在的ID，升序排列
+func (l *Loader) Missing() []int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	ids := make([]int, 0, len(l.missing))
+	for id := range l.missing {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+//enqueue 调用方需持有锁
+func (l *Loader) enqueue(ctx context.Context, id int) *result {
+	if r, ok := l.results[id]; ok {
+		return r
+	}
+
+	r := &result{done: make(chan struct{})}
+	l.results[id] = r
+	if len(l.pending) == 0 {
+		l.batchCtx = ctx
+	}
+	l.pending = append(l.pending, id)
+
+	if len(l.pending) >= l.maxBatch {
+		l.flush()
+	} else if l.timer == nil {
+		l.timer = time.AfterFunc(l.wait, func() {
+			l.lock.Lock()
+			l.flush()
+			l.lock.Unlock()
+		})
+	}
+	return r
+}
+
+//flush 取出待查询的ID异步执行，调用方需持有锁
+func (l *Loader) flush() {
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	if len(l.pending) == 0 {
+		return
+	}
+
+	ids := l.pending
+	ctx := l.batchCtx
+	results := make(map[int]*result, len(ids))
+	for _, id := range ids {
+		results[id] = l.results[id]
+	}
+	l.pending = nil
+	l.batchCtx = nil
+
+	go l.dispatch(ctx, ids, results)
+}
+
+func (l *Loader) dispatch(ctx context.Context, ids []int, results map[int]*result) {
+	values, err := l.batch(ctx, ids)
+
+	l.lock.Lock()
+	for id, r := range results {
+		if err != nil {
+			//失败的结果不缓存，后续Load可以重试
+			r.err = err
+			delete(l.results, id)
+		} else if value, ok := values[id]; ok {
+			r.value = value
+		} else {
+			l.missing[id] = true
+		}
+	}
+	l.lock.Unlock()
+
+	for _, r := range results {
+		close(r.done)
+	}
+}
+
+func wait(ctx context.Context, r *result) (interface{}, error) {
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
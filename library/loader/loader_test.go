@@ -0,0 +1,187 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+//recorder 记录每次batch调用的ID，ID为负数时不存在
+type recorder struct {
+	lock    sync.Mutex
+	batches [][]int
+	err     error
+}
+
+func (r *recorder) batch(ctx context.Context, ids []int) (map[int]interface{}, error) {
+	r.lock.Lock()
+	sorted := append([]int{}, ids...)
+	sort.Ints(sorted)
+	r.batches = append(r.batches, sorted)
+	err := r.err
+	r.lock.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[int]interface{}, len(ids))
+	for _, id := range ids {
+		if id > 0 {
+			values[id] = id * 10
+		}
+	}
+	return values, nil
+}
+
+func (r *recorder) calls() [][]int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([][]int{}, r.batches...)
+}
+
+func TestLoadCoalesces(t *testing.T) {
+	r := &recorder{}
+	l := New(r.batch, 20*time.Millisecond, 0)
+
+	var wg sync.WaitGroup
+	results := make([]interface{}, 3)
+	for i, id := range []int{1, 2, 1} {
+		wg.Add(1)
+		go func(i, id int) {
+			defer wg.Done()
+			value, err := l.Load(context.Background(), id)
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = value
+		}(i, id)
+	}
+	wg.Wait()
+
+	if want := [][]int{{1, 2}}; !reflect.DeepEqual(r.calls(), want) {
+		t.Errorf("batches = %v, want %v", r.calls(), want)
+	}
+	if want := []interface{}{10, 20, 10}; !reflect.DeepEqual(results, want) {
+		t.Errorf("results = %v, want %v", results, want)
+	}
+
+	//请求内缓存，不再调用batch
+	if value, err := l.Load(context.Background(), 2); err != nil || value != 20 || len(r.calls()) != 1 {
+		t.Errorf("cached Load = %v, %v, batches = %v", value, err, r.calls())
+	}
+}
+
+func TestLoadMaxBatch(t *testing.T) {
+	r := &recorder{}
+	l := New(r.batch, time.Hour, 2)
+
+	values, err := l.LoadMany(context.Background(), []int{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 3 {
+		t.Errorf("values = %v", values)
+	}
+	calls := r.calls()
+	sort.Slice(calls, func(i, j int) bool { return calls[i][0] < calls[j][0] })
+	if want := [][]int{{1, 2}, {3}}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("batches = %v, want %v", calls, want)
+	}
+}
+
+func TestMissing(t *testing.T) {
+	r := &recorder{}
+	l := New(r.batch, 0, 0)
+
+	values, err := l.LoadMany(context.Background(), []int{3, -2, 1, -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[int]interface{}{1: 10, 3: 30}; !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %v, want %v", values, want)
+	}
+	if value, err := l.Load(context.Background(), -1); value != nil || err != nil {
+		t.Errorf("Load missing = %v, %v, want nil, nil", value, err)
+	}
+	if want := []int{-2, -1}; !reflect.DeepEqual(l.Missing(), want) {
+		t.Errorf("Missing = %v, want %v", l.Missing(), want)
+	}
+	if len(r.calls()) != 1 {
+		t.Errorf("batches = %v, missing ids should be cached", r.calls())
+	}
+}
+
+func TestBatchErrorRetry(t *testing.T) {
+	r := &recorder{err: errors.New("redis down")}
+	l := New(r.batch, 0, 0)
+
+	if _, err := l.LoadMany(context.Background(), []int{1, 2}); err != r.err {
+		t.Fatalf("LoadMany err = %v, want %v", err, r.err)
+	}
+	if _, err := l.Load(context.Background(), 1); err != r.err {
+		t.Fatalf("Load err = %v, want %v", err, r.err)
+	}
+
+	//失败的结果不缓存，恢复后重新查询
+	r.lock.Lock()
+	r.err = nil
+	r.lock.Unlock()
+	value, err := l.Load(context.Background(), 1)
+	if err != nil || value != 10 {
+		t.Errorf("Load after recovery = %v, %v, want 10", value, err)
+	}
+	if len(l.Missing()) != 0 {
+		t.Errorf("Missing = %v, failed ids are not missing", l.Missing())
+	}
+	if len(r.calls()) != 3 {
+		t.Errorf("batches = %v, want 3 calls", r.calls())
+	}
+}
+
+func TestLoadContextCanceled(t *testing.T) {
+	l := New(func(ctx context.Context, ids []int) (map[int]interface{}, error) {
+		time.Sleep(50 * time.Millisecond)
+		return nil, nil
+	}, 0, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := l.Load(ctx, 1); err != context.DeadlineExceeded {
+		t.Errorf("Load err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestNewMap(t *testing.T) {
+	type detail struct{ Id int }
+	l := NewMap(func(ctx context.Context, ids []int) (map[int]*detail, error) {
+		details := make(map[int]*detail)
+		for _, id := range ids {
+			if id != 2 {
+				details[id] = &detail{Id: id}
+			}
+		}
+		return details, nil
+	}, 0, 0)
+
+	dst := make(map[int]*detail)
+	if err := l.LoadManyInto(context.Background(), []int{1, 2, 3}, dst); err != nil {
+		t.Fatal(err)
+	}
+	if len(dst) != 2 || dst[1].Id != 1 || dst[3].Id != 3 {
+		t.Errorf("dst = %v", dst)
+	}
+	if want := []int{2}; !reflect.DeepEqual(l.Missing(), want) {
+		t.Errorf("Missing = %v, want %v", l.Missing(), want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("NewMap accepted a batch with the wrong signature")
+		}
+	}()
+	NewMap(func(ids []int) map[int]int { return nil }, 0, 0)
+}
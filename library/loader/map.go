@@ -0,0 +1,53 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	idsType     = reflect.TypeOf([]int(nil))
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+//NewMap batch为 func(ctx context.Context, ids []int) (map[int]T, error)，如ProductLibrary.BatchProductDetail
+//各数据源不需要再各自转换map[int]interface{}，结果用LoadManyInto写回map[int]T
+//batch的签名不符时panic，属于启动时的编程错误
+func NewMap(batch interface{}, wait time.Duration, maxBatch int) *Loader {
+	fn := reflect.ValueOf(batch)
+	t := fn.Type()
+	if t.Kind() != reflect.Func || t.NumIn() != 2 || t.NumOut() != 2 ||
+		t.In(0) != contextType || t.In(1) != idsType ||
+		t.Out(0).Kind() != reflect.Map || t.Out(0).Key().Kind() != reflect.Int || t.Out(1) != errorType {
+		panic(fmt.Sprintf("loader: batch must be func(context.Context, []int) (map[int]T, error), got %s", t))
+	}
+
+	return New(func(ctx context.Context, ids []int) (map[int]interface{}, error) {
+		out := fn.Call([]reflect.Value{reflect.ValueOf(&ctx).Elem(), reflect.ValueOf(ids)})
+		if err, _ := out[1].Interface().(error); err != nil {
+			return nil, err
+		}
+		values := make(map[int]interface{}, out[0].Len())
+		iter := out[0].MapRange()
+		for iter.Next() {
+			values[int(iter.Key().Int())] = iter.Value().Interface()
+		}
+		return values, nil
+	}, wait, maxBatch)
+}
+
+//LoadManyInto 同LoadMany，结果写入dst，dst为非nil的map[int]T，T与NewMap的batch返回值一致
+func (l *Loader) LoadManyInto(ctx context.Context, ids []int, dst interface{}) error {
+	values, err := l.LoadMany(ctx, ids)
+	if err != nil {
+		return err
+	}
+	m := reflect.ValueOf(dst)
+	for id, value := range values {
+		m.SetMapIndex(reflect.ValueOf(id), reflect.ValueOf(value))
+	}
+	return nil
+}
@@ -2,77 +2,93 @@ package location
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log"
 	"strconv"
-	"sync"
 
-	"github.com/why444216978/go-library/libraries/config"
-	"github.com/why444216978/go-library/libraries/redis"
-	"github.com/why444216978/go-library/libraries/util"
-	"github.com/why444216978/go-library/libraries/util/conversion"
+	"gin-frame/library/redis_client"
 
 	redigo "github.com/gomodule/redigo/redis"
 )
 
-type LocationLibrary struct {
-	redis *redis.RedisDB
+//LocationDetail location::id_detail:{id}中存储的地区详情
+type LocationDetail struct {
+	Id       int    `json:"id"`
+	Name     string `json:"name"`
+	ParentId int    `json:"parent_id"`
+	Level    int    `json:"level"`
+	FullName string `json:"full_name"`
 }
 
-var location *LocationLibrary
-var onceLibraryLocation sync.Once
+type LocationLibrary struct {
+	redis *redis_client.Client
+}
 
 const (
-	redisName         = "location"
+	RedisName         = "location"
 	locationDetailKey = "location::id_detail:"
 	locationNameKey   = "location::id_name:"
 )
 
-func NewObj() *LocationLibrary {
-	onceLibraryLocation.Do(func() {
-		location = &LocationLibrary{}
-
-		location.redis = location.getRedis()
-
-		log.Printf("new library location")
-	})
-	return location
-}
-
-func (location *LocationLibrary) getRedis() *redis.RedisDB {
-	fileCfg := config.GetConfig("redis", redisName)
+func NewLocationLibrary(registry *redis_client.Registry) (*LocationLibrary, error) {
+	db, err := registry.Get(RedisName)
+	if err != nil {
+		return nil, err
+	}
 
-	hostCfg := fileCfg.Key("host").String()
-	passwordCfg := fileCfg.Key("auth").String()
-	portCfg, err := fileCfg.Key("port").Int()
-	dbCfg, err := fileCfg.Key("db").Int()
-	maxActiveCfg, err := fileCfg.Key("max_active").Int()
-	maxIdleCfg, err := fileCfg.Key("max_idle").Int()
-	logCfg, err := fileCfg.Key("is_log").Bool()
-	execTime, err := fileCfg.Key("exec_timeout").Int64()
-	util.Must(err)
+	location := &LocationLibrary{}
+	location.redis = db
 
-	db, err := redis.Conn("location", hostCfg, passwordCfg, portCfg, dbCfg, maxActiveCfg, maxIdleCfg, logCfg, execTime)
+	log.Printf("new library location")
 
-	return db
+	return location, nil
 }
 
-func (location *LocationLibrary) GetLocationDetail(ctx context.Context, id int) map[string]interface{} {
-	db := location.getRedis()
-
-	data, _ := redigo.String(db.Do(ctx, "GET", locationDetailKey+strconv.Itoa(id)))
+//GetLocationDetail key不存在时返回nil
+func (location *LocationLibrary) GetLocationDetail(ctx context.Context, id int) (*LocationDetail, error) {
+	data, err := redigo.Bytes(location.redis.Do(ctx, "GET", locationDetailKey+strconv.Itoa(id)))
+	if err == redigo.ErrNil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get location %d: %w", id, err)
+	}
 
-	return conversion.JsonToMap(data)
+	detail := &LocationDetail{}
+	if err := json.Unmarshal(data, detail); err != nil {
+		return nil, fmt.Errorf("decode location %d: %w", id, err)
+	}
+	return detail, nil
 }
 
-func (location *LocationLibrary) BatchLocationDetail(ctx context.Context, ids []int) []string {
-	db := location.getRedis()
+//BatchLocationDetail 使用一次MGET查询多个location，返回结果中不包含不存在的ID
+func (location *LocationLibrary) BatchLocationDetail(ctx context.Context, ids []int) (map[int]*LocationDetail, error) {
+	details := make(map[int]*LocationDetail, len(ids))
+	if len(ids) == 0 {
+		return details, nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, v := range ids {
+		args[i] = locationDetailKey + strconv.Itoa(v)
+	}
 
-	var args []interface{}
-	for _, v := range ids {
-		args = append(args, locationDetailKey+strconv.Itoa(v))
+	data, err := redigo.ByteSlices(location.redis.Do(ctx, "MGET", args...))
+	if err != nil {
+		return nil, fmt.Errorf("batch get location: %w", err)
 	}
 
-	data, _ := redigo.Strings(db.Do(ctx, "MGET", args...))
+	for i, v := range data {
+		if v == nil {
+			continue
+		}
+		detail := &LocationDetail{}
+		if err := json.Unmarshal(v, detail); err != nil {
+			return nil, fmt.Errorf("decode location %d: %w", ids[i], err)
+		}
+		details[ids[i]] = detail
+	}
 
-	return data
+	return details, nil
 }
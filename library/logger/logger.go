@@ -0,0 +1,247 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gin-frame/config"
+
+	go_log "github.com/why444216978/go-library/libraries/log"
+	"github.com/why444216978/go-library/libraries/util/sys"
+)
+
+const (
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+type entry struct {
+	shard int
+	line  []byte
+}
+
+//Logger 启动时创建的异步日志，请求中只做json编码和一次非阻塞的channel发送
+//单独的写协程负责按周期切换文件、刷盘和清理过期文件
+type Logger struct {
+	config *config.LogFile
+	prefix string
+	files  []*rotateFile
+
+	lock    sync.RWMutex
+	closed  bool
+	entries chan entry
+	done    chan struct{}
+
+	next    uint32
+	dropped int64
+}
+
+//New prefix为文件名前缀，如 gin-frame.log.hostname.
+func New(cfg *config.LogFile, prefix string) *Logger {
+	l := &Logger{
+		config:  cfg,
+		prefix:  prefix,
+		files:   make([]*rotateFile, cfg.Area),
+		entries: make(chan entry, cfg.Buffer),
+		done:    make(chan struct{}),
+	}
+	for i := range l.files {
+		l.files[i] = newRotateFile(cfg.Dir, prefix, i, cfg.Rotate, cfg.MaxSize)
+	}
+
+	go l.run()
+
+	return l
+}
+
+func (l *Logger) Info(header *go_log.LogFormat, fields map[string]interface{}) {
+	l.log(LevelInfo, header, fields)
+}
+
+func (l *Logger) Warn(header *go_log.LogFormat, fields map[string]interface{}) {
+	l.log(LevelWarn, header, fields)
+}
+
+func (l *Logger) Error(header *go_log.LogFormat, fields map[string]interface{}) {
+	l.log(LevelError, header, fields)
+}
+
+//Dropped 队列满时丢弃的日志条数，写协程记录后清零
+func (l *Logger) Dropped() int64 {
+	return atomic.LoadInt64(&l.dropped)
+}
+
+//Close 停止接收日志，等待队列中的日志写入文件，ctx到期时不再等待
+func (l *Logger) Close(ctx context.Context) error {
+	l.lock.Lock()
+	if !l.closed {
+		l.closed = true
+		close(l.entries)
+	}
+	l.lock.Unlock()
+
+	select {
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("flush log %s: %w", l.prefix, ctx.Err())
+	}
+}
+
+//log 在调用方协程中完成编码，写协程只处理字节，不持有请求中的map
+func (l *Logger) log(level string, header *go_log.LogFormat, fields map[string]interface{}) {
+	now := time.Now()
+	data := make(map[string]interface{}, len(fields)+13)
+	for k, v := range fields {
+		data[k] = v
+	}
+	data["level"] = level
+	data["time"] = now.Format("2006-01-02 15:04:05.000")
+	if header != nil {
+		data["log_id"] = header.LogId
+		data["http_code"] = header.HttpCode
+		data["method"] = header.Method
+		data["caller_ip"] = header.CallerIp
+		data["uri_path"] = header.UriPath
+		data["port"] = header.Port
+		data["product"] = header.Product
+		data["module"] = header.Module
+		data["env"] = header.Env
+		if header.XHop != nil {
+			data["x_hop"] = header.XHop.String()
+		}
+		if !header.StartTime.IsZero() {
+			data["start_time"] = header.StartTime.Format("2006-01-02 15:04:05.000")
+		}
+	}
+
+	line, err := json.Marshal(data)
+	if err != nil {
+		line, _ = json.Marshal(map[string]interface{}{
+			"level":        level,
+			"time":         data["time"],
+			"log_id":       data["log_id"],
+			"encode_error": err.Error(),
+		})
+	}
+	line = append(line, '\n')
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	if l.closed {
+		return
+	}
+	select {
+	case l.entries <- entry{shard: l.shard(header), line: line}:
+	default:
+		atomic.AddInt64(&l.dropped, 1)
+	}
+}
+
+//shard 有log_id时按log_id哈希，保证同一个请求的日志在同一个文件中
+func (l *Logger) shard(header *go_log.LogFormat) int {
+	if len(l.files) == 1 {
+		return 0
+	}
+	if header == nil || header.LogId == "" {
+		return int(atomic.AddUint32(&l.next, 1) % uint32(len(l.files)))
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(header.LogId))
+	return int(h.Sum32() % uint32(len(l.files)))
+}
+
+func (l *Logger) run() {
+	defer close(l.done)
+
+	flushTicker := time.NewTicker(l.config.FlushInterval)
+	defer flushTicker.Stop()
+	cleanupTicker := time.NewTicker(time.Hour)
+	defer cleanupTicker.Stop()
+
+	l.cleanup()
+	for {
+		select {
+		case e, ok := <-l.entries:
+			if !ok {
+				l.reportDropped()
+				for _, f := range l.files {
+					if err := f.close(); err != nil {
+						log.Printf("close log %s: %v", l.prefix, err)
+					}
+				}
+				return
+			}
+			if err := l.files[e.shard].write(time.Now(), e.line); err != nil {
+				log.Printf("write log %s: %v", l.prefix, err)
+			}
+		case <-flushTicker.C:
+			l.reportDropped()
+			for _, f := range l.files {
+				if err := f.flush(); err != nil {
+					log.Printf("flush log %s: %v", l.prefix, err)
+				}
+			}
+		case <-cleanupTicker.C:
+			l.cleanup()
+		}
+	}
+}
+
+//reportDropped 把丢弃的条数作为一条warn日志写入第一个分片
+func (l *Logger) reportDropped() {
+	dropped := atomic.SwapInt64(&l.dropped, 0)
+	if dropped == 0 {
+		return
+	}
+
+	line, _ := json.Marshal(map[string]interface{}{
+		"level":   LevelWarn,
+		"time":    time.Now().Format("2006-01-02 15:04:05.000"),
+		"msg":     "log buffer full",
+		"dropped": dropped,
+	})
+	if err := l.files[0].write(time.Now(), append(line, '\n')); err != nil {
+		log.Printf("write log %s: %v", l.prefix, err)
+	}
+}
+
+func (l *Logger) cleanup() {
+	if l.config.MaxAge <= 0 {
+		return
+	}
+	if err := cleanup(l.config.Dir, l.prefix, l.config.MaxAge, time.Now()); err != nil {
+		log.Printf("%v", err)
+	}
+}
+
+//Loggers log.ini中[run]和[error]对应的日志
+type Loggers struct {
+	Run   *Logger
+	Error *Logger
+}
+
+//NewLoggers 按log.ini的[run]和[error]创建，运行日志默认按小时切换，错误日志默认按天切换
+func NewLoggers(cfg *config.Config) *Loggers {
+	module := cfg.App.Module
+	return &Loggers{
+		Run:   New(&cfg.Log.Run, module+".log."+sys.HostName()+"."),
+		Error: New(&cfg.Log.Error, module+".err."+sys.HostName()+"."),
+	}
+}
+
+//Close 先关闭运行日志再关闭错误日志，返回第一个错误
+func (loggers *Loggers) Close(ctx context.Context) error {
+	err := loggers.Run.Close(ctx)
+	if errorErr := loggers.Error.Close(ctx); err == nil {
+		err = errorErr
+	}
+	return err
+}
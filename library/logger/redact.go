@@ -0,0 +1,157 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//RedactedMark 脱敏后的值
+const RedactedMark = "[REDACTED]"
+
+//phonePattern 11位手机号，前后不能是数字，保留前3位和后4位
+var phonePattern = regexp.MustCompile(`(^|\D)(1[3-9]\d)\d{4}(\d{4})(\D|$)`)
+
+//Redactor 日志脱敏，header和字段名不区分大小写
+type Redactor struct {
+	headers   map[string]bool
+	fields    map[string]bool
+	maskPhone bool
+
+	//jsonPattern、formPattern 在无法解码的文本(如截断的body)中按字段名匹配值，没有字段时为nil
+	jsonPattern *regexp.Regexp
+	formPattern *regexp.Regexp
+}
+
+//NewRedactor headers为整体替换的请求头，fields为json/form中整体替换的字段名，maskPhone为true时隐藏字符串中手机号的中间4位
+func NewRedactor(headers, fields []string, maskPhone bool) *Redactor {
+	r := &Redactor{
+		headers:   make(map[string]bool, len(headers)),
+		fields:    make(map[string]bool, len(fields)),
+		maskPhone: maskPhone,
+	}
+	for _, h := range headers {
+		if h = strings.TrimSpace(h); h != "" {
+			r.headers[http.CanonicalHeaderKey(h)] = true
+		}
+	}
+	var names []string
+	for _, f := range fields {
+		if f = strings.TrimSpace(f); f != "" {
+			r.fields[strings.ToLower(f)] = true
+			names = append(names, regexp.QuoteMeta(f))
+		}
+	}
+	if len(names) > 0 {
+		keys := strings.Join(names, "|")
+		//值可能被截断，字符串缺少结尾的引号时匹配到末尾
+		r.jsonPattern = regexp.MustCompile(`(?i)("(?:` + keys + `)"\s*:\s*)(?:"(?:[^"\\]|\\.)*(?:"|\\?$)|[^,}\]\s]*)`)
+		r.formPattern = regexp.MustCompile(`(?i)((?:^|&)(?:` + keys + `)=)[^&]*`)
+	}
+	return r
+}
+
+//Header 返回脱敏后的副本，不修改原请求头
+func (r *Redactor) Header(header http.Header) http.Header {
+	redacted := make(http.Header, len(header))
+	for k, values := range header {
+		if r.headers[http.CanonicalHeaderKey(k)] {
+			redacted[k] = []string{RedactedMark}
+			continue
+		}
+		copied := make([]string, len(values))
+		for i, v := range values {
+			copied[i] = r.String(v)
+		}
+		redacted[k] = copied
+	}
+	return redacted
+}
+
+//Value 递归处理json解码后的map/slice，返回脱敏后的副本
+func (r *Redactor) Value(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			if r.fields[strings.ToLower(k)] {
+				redacted[k] = RedactedMark
+				continue
+			}
+			redacted[k] = r.Value(item)
+		}
+		return redacted
+	case map[string][]string:
+		redacted := make(map[string]interface{}, len(value))
+		for k, items := range value {
+			if r.fields[strings.ToLower(k)] {
+				redacted[k] = RedactedMark
+				continue
+			}
+			copied := make([]string, len(items))
+			for i, item := range items {
+				copied[i] = r.String(item)
+			}
+			redacted[k] = copied
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(value))
+		for i, item := range value {
+			redacted[i] = r.Value(item)
+		}
+		return redacted
+	case []string:
+		redacted := make([]string, len(value))
+		for i, item := range value {
+			redacted[i] = r.String(item)
+		}
+		return redacted
+	case string:
+		return r.String(value)
+	case float64:
+		//json解码后数字是float64，手机号按数字传时也要隐藏
+		return r.number(strconv.FormatFloat(value, 'f', -1, 64), v)
+	case json.Number:
+		return r.number(string(value), v)
+	case int:
+		return r.number(strconv.Itoa(value), v)
+	case int64:
+		return r.number(strconv.FormatInt(value, 10), v)
+	case uint64:
+		return r.number(strconv.FormatUint(value, 10), v)
+	}
+	return v
+}
+
+//number 数字中含手机号时返回隐藏后的字符串，否则原样返回，不改变数字类型
+func (r *Redactor) number(s string, v interface{}) interface{} {
+	if masked := r.String(s); masked != s {
+		return masked
+	}
+	return v
+}
+
+//Text 处理无法解码的json/form文本，如超过MaxBody被截断的body
+//按字段名替换 "key": value 和 key=value 中的值，再隐藏手机号
+func (r *Redactor) Text(s string) string {
+	if r.jsonPattern != nil {
+		s = r.jsonPattern.ReplaceAllString(s, `${1}"`+RedactedMark+`"`)
+		s = r.formPattern.ReplaceAllString(s, "${1}"+RedactedMark)
+	}
+	return r.String(s)
+}
+
+//String 隐藏字符串中的手机号
+func (r *Redactor) String(s string) string {
+	if !r.maskPhone {
+		return s
+	}
+	//相邻的手机号共用分隔符，替换一次后可能还有未匹配到的，最多重复两次
+	for i := 0; i < 2 && phonePattern.MatchString(s); i++ {
+		s = phonePattern.ReplaceAllString(s, "${1}${2}****${3}${4}")
+	}
+	return s
+}
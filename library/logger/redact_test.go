@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func newTestRedactor() *Redactor {
+	return NewRedactor([]string{"Authorization"}, []string{"password", "token"}, true)
+}
+
+func TestRedactorValue(t *testing.T) {
+	r := newTestRedactor()
+	cases := []struct {
+		name string
+		in   interface{}
+		want interface{}
+	}{
+		{
+			name: "nested json",
+			in: map[string]interface{}{
+				"user": map[string]interface{}{
+					"Password": "secret",
+					"phone":    "13812345678",
+					"devices":  []interface{}{map[string]interface{}{"token": "abc", "id": float64(1)}},
+				},
+			},
+			want: map[string]interface{}{
+				"user": map[string]interface{}{
+					"Password": RedactedMark,
+					"phone":    "138****5678",
+					"devices":  []interface{}{map[string]interface{}{"token": RedactedMark, "id": float64(1)}},
+				},
+			},
+		},
+		{
+			name: "phone as number",
+			in: map[string]interface{}{
+				"phone":  float64(13812345678),
+				"mobile": json.Number("13912345678"),
+				"uid":    int64(13712345678),
+				"id":     float64(1),
+				"price":  float64(12.5),
+			},
+			want: map[string]interface{}{
+				"phone":  "138****5678",
+				"mobile": "139****5678",
+				"uid":    "137****5678",
+				"id":     float64(1),
+				"price":  float64(12.5),
+			},
+		},
+		{
+			name: "form",
+			in:   map[string][]string{"password": {"secret"}, "name": {"a", "13812345678"}},
+			want: map[string]interface{}{"password": RedactedMark, "name": []string{"a", "138****5678"}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := r.Value(c.in); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("Value() = %#v, want %#v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestRedactorText(t *testing.T) {
+	r := newTestRedactor()
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"json", `{"name":"a","password":"se\"cret","token": 123,"x":1}`, `{"name":"a","password":"[REDACTED]","token": "[REDACTED]","x":1}`},
+		{"json truncated in value", `{"name":"a","Password":"secr`, `{"name":"a","Password":"[REDACTED]"`},
+		{"json truncated after colon", `{"user":{"token":`, `{"user":{"token":"[REDACTED]"`},
+		{"json truncated in key", `{"name":"a","passw`, `{"name":"a","passw`},
+		{"form", `name=a&password=secret&token=abc`, `name=a&password=[REDACTED]&token=[REDACTED]`},
+		{"form truncated", `name=a&password=sec`, `name=a&password=[REDACTED]`},
+		{"form similar key", `old_password=a&passwords=b`, `old_password=a&passwords=b`},
+		{"phone", `mobile=13812345678`, `mobile=138****5678`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := r.Text(c.in); got != c.want {
+				t.Errorf("Text(%q) = %q, want %q", c.in, got, c.want)
+			}
+		})
+	}
+}
+
+func TestRedactorHeader(t *testing.T) {
+	r := newTestRedactor()
+	header := http.Header{"Authorization": {"Bearer x"}, "X-Phone": {"13812345678"}}
+	got := r.Header(header)
+	if got.Get("Authorization") != RedactedMark || got.Get("X-Phone") != "138****5678" {
+		t.Errorf("Header() = %v", got)
+	}
+	if header.Get("Authorization") != "Bearer x" {
+		t.Errorf("Header() modified the original header")
+	}
+}
@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	RotateHour = "hour"
+	RotateDay  = "day"
+)
+
+//rotateFile 一个分片对应的日志文件，按周期切换文件，单个文件超过maxSize时在同一周期内追加序号
+//文件名格式为 dir/prefix周期.分片[.序号]，如 logs/run/gin-frame.log.host.2020070712.3.1
+//只在Logger的写协程中使用，不需要加锁
+type rotateFile struct {
+	dir     string
+	prefix  string
+	shard   int
+	layout  string
+	maxSize int64
+
+	period string
+	seq    int
+	size   int64
+	file   *os.File
+	writer *bufio.Writer
+}
+
+func newRotateFile(dir, prefix string, shard int, rotate string, maxSize int64) *rotateFile {
+	layout := "2006010215"
+	if rotate == RotateDay {
+		layout = "20060102"
+	}
+	return &rotateFile{
+		dir:     dir,
+		prefix:  prefix,
+		shard:   shard,
+		layout:  layout,
+		maxSize: maxSize,
+	}
+}
+
+func (f *rotateFile) write(now time.Time, line []byte) error {
+	period := now.Format(f.layout)
+	if f.file == nil || period != f.period {
+		if err := f.open(period, 0); err != nil {
+			return err
+		}
+	} else if f.maxSize > 0 && f.size+int64(len(line)) > f.maxSize {
+		if err := f.open(period, f.seq+1); err != nil {
+			return err
+		}
+	}
+
+	n, err := f.writer.Write(line)
+	f.size += int64(n)
+	return err
+}
+
+//open 打开周期period的第seq个文件，进程重启后跳过已经写满的文件
+func (f *rotateFile) open(period string, seq int) error {
+	if err := f.close(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(f.dir, 0755); err != nil {
+		return err
+	}
+
+	for {
+		name := f.name(period, seq)
+		info, err := os.Stat(name)
+		if err == nil && f.maxSize > 0 && info.Size() >= f.maxSize {
+			seq++
+			continue
+		}
+
+		file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		size := int64(0)
+		if info != nil {
+			size = info.Size()
+		}
+
+		f.period = period
+		f.seq = seq
+		f.size = size
+		f.file = file
+		f.writer = bufio.NewWriterSize(file, 32*1024)
+		return nil
+	}
+}
+
+func (f *rotateFile) name(period string, seq int) string {
+	name := fmt.Sprintf("%s%s.%d", f.prefix, period, f.shard)
+	if seq > 0 {
+		name = fmt.Sprintf("%s.%d", name, seq)
+	}
+	return filepath.Join(f.dir, name)
+}
+
+func (f *rotateFile) flush() error {
+	if f.writer == nil {
+		return nil
+	}
+	return f.writer.Flush()
+}
+
+func (f *rotateFile) close() error {
+	if f.file == nil {
+		return nil
+	}
+	err := f.writer.Flush()
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	f.file = nil
+	f.writer = nil
+	return err
+}
+
+//cleanup 删除dir下以prefix开头、最后修改时间早于maxAge的文件
+func cleanup(dir, prefix string, maxAge time.Duration, now time.Time) error {
+	files, err := filepath.Glob(filepath.Join(dir, prefix+"*"))
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	for _, name := range files {
+		info, err := os.Stat(name)
+		if err != nil || info.IsDir() || now.Sub(info.ModTime()) <= maxAge {
+			continue
+		}
+		if err := os.Remove(name); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("cleanup %s: %s", dir, strings.Join(problems, ", "))
+	}
+	return nil
+}
@@ -2,74 +2,93 @@ package product
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log"
 	"strconv"
-	"sync"
 
-	"github.com/why444216978/go-library/libraries/config"
-	"github.com/why444216978/go-library/libraries/redis"
-	"github.com/why444216978/go-library/libraries/util/conversion"
-	"github.com/why444216978/go-library/libraries/util/error"
+	"gin-frame/library/redis_client"
 
 	redigo "github.com/gomodule/redigo/redis"
 )
 
-type ProductLibrary struct {
-	redis *redis.RedisDB
+//ProductDetail product::id_detail:{id}中存储的品类详情
+type ProductDetail struct {
+	Id       int    `json:"id"`
+	Name     string `json:"name"`
+	ParentId int    `json:"parent_id"`
+	Level    int    `json:"level"`
+	Unit     string `json:"unit"`
 }
 
-var product *ProductLibrary
-var onceLibraryProduct sync.Once
+type ProductLibrary struct {
+	redis *redis_client.Client
+}
 
 const (
-	redisName        = "product"
+	RedisName        = "product"
 	productDetailKey = "product::id_detail:"
 	productNameKey   = "product::id_name:"
 )
 
-func NewObj() *ProductLibrary {
-	onceLibraryProduct.Do(func() {
-		product = &ProductLibrary{}
-
-		product.redis = product.getRedis()
+func NewProductLibrary(registry *redis_client.Registry) (*ProductLibrary, error) {
+	db, err := registry.Get(RedisName)
+	if err != nil {
+		return nil, err
+	}
 
-		log.Printf("new library product")
-	})
-	return product
-}
+	product := &ProductLibrary{}
+	product.redis = db
 
-func (product *ProductLibrary) getRedis() *redis.RedisDB {
-	fileCfg := config.GetConfig("redis", redisName)
+	log.Printf("new library product")
 
-	hostCfg := fileCfg.Key("host").String()
-	passwordCfg := fileCfg.Key("auth").String()
-	portCfg, err := fileCfg.Key("port").Int()
-	dbCfg, err := fileCfg.Key("db").Int()
-	maxActiveCfg, err := fileCfg.Key("max_active").Int()
-	maxIdleCfg, err := fileCfg.Key("max_idle").Int()
-	logCfg, err := fileCfg.Key("is_log").Bool()
-	execTime, err := fileCfg.Key("exec_timeout").Int64()
-	error.Must(err)
+	return product, nil
+}
 
-	db, err := redis.Conn("product", hostCfg, passwordCfg, portCfg, dbCfg, maxActiveCfg, maxIdleCfg, logCfg, execTime)
-	error.Must(err)
+//GetProductDetail key不存在时返回nil
+func (self *ProductLibrary) GetProductDetail(ctx context.Context, id int) (*ProductDetail, error) {
+	data, err := redigo.Bytes(self.redis.Do(ctx, "GET", productDetailKey+strconv.Itoa(id)))
+	if err == redigo.ErrNil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get product %d: %w", id, err)
+	}
 
-	return db
+	detail := &ProductDetail{}
+	if err := json.Unmarshal(data, detail); err != nil {
+		return nil, fmt.Errorf("decode product %d: %w", id, err)
+	}
+	return detail, nil
 }
 
-func (self *ProductLibrary) GetProductDetail(ctx context.Context, id int) map[string]interface{} {
-	data, _ := redigo.String(self.redis.Do(ctx, "GET", productDetailKey+strconv.Itoa(id)))
+//BatchProductDetail 使用一次MGET查询多个product，返回结果中不包含不存在的ID
+func (self *ProductLibrary) BatchProductDetail(ctx context.Context, ids []int) (map[int]*ProductDetail, error) {
+	details := make(map[int]*ProductDetail, len(ids))
+	if len(ids) == 0 {
+		return details, nil
+	}
 
-	return conversion.JsonToMap(data)
-}
+	args := make([]interface{}, len(ids))
+	for i, v := range ids {
+		args[i] = productDetailKey + strconv.Itoa(v)
+	}
 
-func (self *ProductLibrary) BatchProductDetail(ctx context.Context, ids []int) []string {
-	var args []interface{}
-	for _, v := range ids {
-		args = append(args, productDetailKey+strconv.Itoa(v))
+	data, err := redigo.ByteSlices(self.redis.Do(ctx, "MGET", args...))
+	if err != nil {
+		return nil, fmt.Errorf("batch get product: %w", err)
 	}
 
-	data, _ := redigo.Strings(self.redis.Do(ctx, "MGET", args...))
+	for i, v := range data {
+		if v == nil {
+			continue
+		}
+		detail := &ProductDetail{}
+		if err := json.Unmarshal(v, detail); err != nil {
+			return nil, fmt.Errorf("decode product %d: %w", ids[i], err)
+		}
+		details[ids[i]] = detail
+	}
 
-	return data
+	return details, nil
 }
@@ -0,0 +1,64 @@
+package redis_client
+
+import (
+	"log"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	commandTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "redis_commands_total",
+		Help: "redis命令数，result为ok或error",
+	}, []string{"name", "command", "result"})
+
+	commandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "redis_command_duration_seconds",
+		Help:    "redis命令耗时，包含从连接池获取连接的时间",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"name", "command"})
+
+	poolActiveDesc = prometheus.NewDesc("redis_pool_active_connections", "redis连接池中的连接数，包含空闲连接", []string{"name"}, nil)
+	poolIdleDesc   = prometheus.NewDesc("redis_pool_idle_connections", "redis连接池中的空闲连接数", []string{"name"}, nil)
+	poolMaxDesc    = prometheus.NewDesc("redis_pool_max_active_connections", "redis连接池max_active配置", []string{"name"}, nil)
+)
+
+func init() {
+	prometheus.MustRegister(commandTotal, commandDuration)
+}
+
+func observeCommand(name, command string, seconds float64, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	commandTotal.WithLabelValues(name, command, result).Inc()
+	commandDuration.WithLabelValues(name, command).Observe(seconds)
+}
+
+//poolCollector 采集时读取Registry中全部连接池的状态
+type poolCollector struct {
+	registry *Registry
+}
+
+func (collector *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolActiveDesc
+	ch <- poolIdleDesc
+	ch <- poolMaxDesc
+}
+
+func (collector *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, stats := range collector.registry.Stats() {
+		ch <- prometheus.MustNewConstMetric(poolActiveDesc, prometheus.GaugeValue, float64(stats.ActiveCount), stats.Name)
+		ch <- prometheus.MustNewConstMetric(poolIdleDesc, prometheus.GaugeValue, float64(stats.IdleCount), stats.Name)
+		ch <- prometheus.MustNewConstMetric(poolMaxDesc, prometheus.GaugeValue, float64(stats.MaxActive), stats.Name)
+	}
+}
+
+//registerPoolCollector 同一进程只注册第一个Registry，测试中重复创建Registry不会panic
+func registerPoolCollector(registry *Registry) {
+	err := prometheus.Register(&poolCollector{registry: registry})
+	if _, ok := err.(prometheus.AlreadyRegisteredError); err != nil && !ok {
+		log.Printf("register redis pool metrics: %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package redis_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gin-frame/config"
+
+	redigo "github.com/gomodule/redigo/redis"
+)
+
+//Client 一个section对应的连接池
+type Client struct {
+	name   string
+	config *config.Redis
+	pool   *redigo.Pool
+}
+
+func newClient(name string, cfg *config.Redis) *Client {
+	address := cfg.Host + ":" + strconv.Itoa(cfg.Port)
+	pool := &redigo.Pool{
+		MaxIdle:     cfg.MaxIdle,
+		MaxActive:   cfg.MaxActive,
+		IdleTimeout: cfg.IdleTimeout,
+		Wait:        true,
+		Dial: func() (redigo.Conn, error) {
+			return redigo.Dial("tcp", address,
+				redigo.DialPassword(cfg.Auth),
+				redigo.DialDatabase(cfg.Db),
+				redigo.DialConnectTimeout(cfg.ConnectTimeout),
+				redigo.DialReadTimeout(cfg.ReadTimeout),
+				redigo.DialWriteTimeout(cfg.WriteTimeout))
+		},
+		TestOnBorrow: func(c redigo.Conn, t time.Time) error {
+			if time.Since(t) < time.Minute {
+				return nil
+			}
+			_, err := c.Do("PING")
+			return err
+		},
+	}
+	return &Client{name: name, config: cfg, pool: pool}
+}
+
+func (client *Client) Name() string {
+	return client.name
+}
+
+//Do 从连接池取连接执行命令，ctx取消时不再等待空闲连接
+func (client *Client) Do(ctx context.Context, commandName string, args ...interface{}) (interface{}, error) {
+	span := client.startSpan(ctx, commandName, args)
+	start := time.Now()
+	conn, err := client.pool.GetContext(ctx)
+	if err != nil {
+		err = fmt.Errorf("redis %s get conn: %w", client.name, err)
+		observeCommand(client.name, commandName, time.Since(start).Seconds(), err)
+		finishSpan(span, err)
+		return nil, err
+	}
+	defer conn.Close()
+
+	execStart := time.Now()
+	reply, err := conn.Do(commandName, args...)
+	observeCommand(client.name, commandName, time.Since(start).Seconds(), err)
+	finishSpan(span, err)
+	if cost := time.Since(execStart); client.config.IsLog && cost > client.config.ExecTimeout {
+		log.Printf("redis %s slow command %s cost %s", client.name, commandName, cost)
+	}
+	return reply, err
+}
+
+//Stats 连接池状态
+type Stats struct {
+	Name        string `json:"name"`
+	ActiveCount int    `json:"active_count"`
+	IdleCount   int    `json:"idle_count"`
+	MaxActive   int    `json:"max_active"`
+	MaxIdle     int    `json:"max_idle"`
+}
+
+func (client *Client) Stats() Stats {
+	stats := client.pool.Stats()
+	return Stats{
+		Name:        client.name,
+		ActiveCount: stats.ActiveCount,
+		IdleCount:   stats.IdleCount,
+		MaxActive:   client.config.MaxActive,
+		MaxIdle:     client.config.MaxIdle,
+	}
+}
+
+func (client *Client) Close() error {
+	return client.pool.Close()
+}
+
+//Registry 按redis.ini的section管理连接池，每个section只创建一次
+type Registry struct {
+	configs map[string]*config.Redis
+
+	lock    sync.Mutex
+	clients map[string]*Client
+	closed  bool
+}
+
+//NewRegistry 只能获取启动时已加载校验的section，见config.Resources
+func NewRegistry(cfg *config.Config) *Registry {
+	registry := &Registry{configs: cfg.Redis, clients: make(map[string]*Client)}
+	registerPoolCollector(registry)
+	return registry
+}
+
+//Get 返回section对应的连接池，首次获取时创建
+//在启动阶段调用，section未加载时直接返回错误，不会等到请求时才发现
+func (registry *Registry) Get(section string) (*Client, error) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	if registry.closed {
+		return nil, errors.New("redis registry closed")
+	}
+	if client, ok := registry.clients[section]; ok {
+		return client, nil
+	}
+
+	cfg, ok := registry.configs[section]
+	if !ok {
+		return nil, fmt.Errorf("redis section %s is not configured", section)
+	}
+
+	client := newClient(section, cfg)
+	//连接失败不阻止启动，由健康检查暴露
+	if _, err := client.Do(context.Background(), "PING"); err != nil {
+		log.Printf("redis %s ping: %v", section, err)
+	}
+	registry.clients[section] = client
+	log.Printf("new redis client %s", section)
+
+	return client, nil
+}
+
+//Names 已创建的section，按名称排序
+func (registry *Registry) Names() []string {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	names := make([]string, 0, len(registry.clients))
+	for name := range registry.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//Stats 所有连接池的状态，按名称排序
+func (registry *Registry) Stats() []Stats {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	stats := make([]Stats, 0, len(registry.clients))
+	for _, client := range registry.clients {
+		stats = append(stats, client.Stats())
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+//Close 关闭所有连接池，之后Get返回错误
+func (registry *Registry) Close() error {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	registry.closed = true
+	var problems []string
+	for name, client := range registry.clients {
+		if err := client.Close(); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	registry.clients = make(map[string]*Client)
+
+	if len(problems) > 0 {
+		return fmt.Errorf("close redis: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package redis_client
+
+import (
+	"context"
+	"strings"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+//startSpan ctx中有span时为命令创建子span，否则返回nil
+//只记录key前缀，避免把用户ID等写入trace
+func (client *Client) startSpan(ctx context.Context, commandName string, args []interface{}) opentracing.Span {
+	if opentracing.SpanFromContext(ctx) == nil {
+		return nil
+	}
+
+	span, _ := opentracing.StartSpanFromContext(ctx, "redis "+commandName)
+	ext.DBType.Set(span, "redis")
+	ext.DBInstance.Set(span, client.name)
+	ext.PeerHostname.Set(span, client.config.Host)
+	ext.PeerPort.Set(span, uint16(client.config.Port))
+	ext.SpanKindRPCClient.Set(span)
+	span.SetTag("redis.command", commandName)
+	if len(args) > 0 {
+		if key, ok := args[0].(string); ok {
+			span.SetTag("redis.key_prefix", keyPrefix(key))
+		}
+		span.SetTag("redis.args", len(args))
+	}
+	return span
+}
+
+func finishSpan(span opentracing.Span, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.SetTag("error.message", err.Error())
+	}
+	span.Finish()
+}
+
+//keyPrefix 取最后一个:之前的部分，如 product::id_detail:1 为 product::id_detail:
+func keyPrefix(key string) string {
+	if i := strings.LastIndex(key, ":"); i >= 0 {
+		return key[:i+1]
+	}
+	return key
+}
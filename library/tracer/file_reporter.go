@@ -0,0 +1,108 @@
+package tracer
+
+import (
+	"bufio"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-client-go"
+)
+
+//fileReporter 每个span以一行json写入文件，本地调试时代替agent/collector
+//缓冲每flushInterval刷盘一次，span较少时也能及时在文件中看到
+type fileReporter struct {
+	lock   sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+	done   chan struct{}
+	once   sync.Once
+	wg     sync.WaitGroup
+}
+
+func newFileReporter(path string, flushInterval time.Duration) (*fileReporter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	r := &fileReporter{file: file, writer: bufio.NewWriter(file), done: make(chan struct{})}
+	r.wg.Add(1)
+	go r.flushLoop(flushInterval)
+	return r, nil
+}
+
+func (r *fileReporter) flushLoop(flushInterval time.Duration) {
+	defer r.wg.Done()
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.flush()
+		case <-r.done:
+			return
+		}
+	}
+}
+
+func (r *fileReporter) flush() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.writer == nil || r.writer.Buffered() == 0 {
+		return
+	}
+	if err := r.writer.Flush(); err != nil {
+		log.Printf("flush span: %v", err)
+	}
+}
+
+//Report 在span结束时同步调用，写入缓冲后立即返回
+func (r *fileReporter) Report(span *jaeger.Span) {
+	ctx := span.SpanContext()
+	line, err := json.Marshal(map[string]interface{}{
+		"trace_id":    ctx.TraceID().String(),
+		"span_id":     ctx.SpanID().String(),
+		"parent_id":   ctx.ParentID().String(),
+		"operation":   span.OperationName(),
+		"start_time":  span.StartTime().Format("2006-01-02 15:04:05.000000"),
+		"duration_us": span.Duration().Microseconds(),
+		"tags":        span.Tags(),
+	})
+	if err != nil {
+		log.Printf("encode span: %v", err)
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.writer == nil {
+		return
+	}
+	if _, err := r.writer.Write(append(line, '\n')); err != nil {
+		log.Printf("write span: %v", err)
+	}
+}
+
+func (r *fileReporter) Close() {
+	r.once.Do(func() { close(r.done) })
+	r.wg.Wait()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.writer == nil {
+		return
+	}
+	if err := r.writer.Flush(); err != nil {
+		log.Printf("flush span: %v", err)
+	}
+	if err := r.file.Close(); err != nil {
+		log.Printf("close span file: %v", err)
+	}
+	r.writer = nil
+}
@@ -0,0 +1,103 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"gin-frame/config"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+	jaeger_config "github.com/uber/jaeger-client-go/config"
+)
+
+const (
+	ReporterUDP    = "udp"
+	ReporterHTTP   = "http"
+	ReporterMemory = "memory"
+	ReporterFile   = "file"
+	ReporterNone   = "none"
+)
+
+//Tracer 进程内唯一的tracer，创建时设置为opentracing的GlobalTracer
+type Tracer struct {
+	opentracing.Tracer
+
+	closer io.Closer
+	memory *jaeger.InMemoryReporter
+}
+
+//NewTracer 按app.ini的[trace]创建jaeger tracer
+func NewTracer(cfg *config.Config) (*Tracer, error) {
+	return New(&cfg.Trace)
+}
+
+func New(cfg *config.Trace) (*Tracer, error) {
+	jaegerCfg := jaeger_config.Configuration{
+		ServiceName: cfg.ServiceName,
+		Sampler: &jaeger_config.SamplerConfig{
+			Type:  cfg.SamplerType,
+			Param: cfg.SamplerParam,
+		},
+		Reporter: &jaeger_config.ReporterConfig{
+			QueueSize:           cfg.QueueSize,
+			BufferFlushInterval: cfg.FlushInterval,
+		},
+	}
+
+	t := &Tracer{}
+	var options []jaeger_config.Option
+	switch cfg.Reporter {
+	case ReporterUDP:
+		jaegerCfg.Reporter.LocalAgentHostPort = cfg.Endpoint
+	case ReporterHTTP:
+		jaegerCfg.Reporter.CollectorEndpoint = cfg.Endpoint
+	case ReporterMemory:
+		t.memory = jaeger.NewInMemoryReporter()
+		options = append(options, jaeger_config.Reporter(t.memory))
+	case ReporterFile:
+		reporter, err := newFileReporter(cfg.File, cfg.FlushInterval)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, jaeger_config.Reporter(reporter))
+	default:
+		options = append(options, jaeger_config.Reporter(jaeger.NewNullReporter()))
+	}
+
+	tracer, closer, err := jaegerCfg.NewTracer(options...)
+	if err != nil {
+		return nil, fmt.Errorf("new tracer: %w", err)
+	}
+	t.Tracer = tracer
+	t.closer = closer
+	opentracing.SetGlobalTracer(tracer)
+
+	log.Printf("new tracer %s, sampler %s(%v), reporter %s", cfg.ServiceName, cfg.SamplerType, cfg.SamplerParam, cfg.Reporter)
+	return t, nil
+}
+
+//Spans reporter为memory时已上报的span，用于本地调试，其他reporter返回nil
+func (t *Tracer) Spans() []opentracing.Span {
+	if t.memory == nil {
+		return nil
+	}
+	return t.memory.GetSpans()
+}
+
+//Close 上报缓冲中的span，ctx到期时不再等待
+func (t *Tracer) Close(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- t.closer.Close()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("flush tracer: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,55 @@
+package tracer
+
+import (
+	"net/http"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+//Transport 为每个出站请求创建子span，并把span context注入请求头
+//req的context中没有span时直接使用base发送
+type Transport struct {
+	base http.RoundTripper
+}
+
+//NewTransport base为nil时使用http.DefaultTransport
+func NewTransport(base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{base: base}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	parent := opentracing.SpanFromContext(req.Context())
+	if parent == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	span := parent.Tracer().StartSpan("http "+req.Method+" "+req.URL.Host+req.URL.Path, opentracing.ChildOf(parent.Context()))
+	defer span.Finish()
+	ext.SpanKindRPCClient.Set(span)
+	ext.Component.Set(span, "net/http")
+	ext.HTTPMethod.Set(span, req.Method)
+	ext.HTTPUrl.Set(span, req.URL.Scheme+"://"+req.URL.Host+req.URL.Path)
+	ext.PeerHostname.Set(span, req.URL.Hostname())
+
+	//RoundTripper不能修改调用方的req，复制header后注入
+	req = req.Clone(req.Context())
+	if err := Inject(req.Context(), span, req.Header); err != nil {
+		span.SetTag("inject.error", err.Error())
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.SetTag("error.message", err.Error())
+		return nil, err
+	}
+	ext.HTTPStatusCode.Set(span, uint16(resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		ext.Error.Set(span, true)
+	}
+	return resp, nil
+}
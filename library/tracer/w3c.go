@@ -0,0 +1,144 @@
+package tracer
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+//W3C Trace Context的请求头，https://www.w3.org/TR/trace-context/
+const (
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+)
+
+//uberTraceIDKey jaeger的传播格式 {trace-id}:{span-id}:{parent-span-id}:{flags}，flags为十进制
+const uberTraceIDKey = "uber-trace-id"
+
+type traceStateKey struct{}
+
+//ContextWithTraceState 保存入站请求的tracestate，Inject时原样传给下游
+func ContextWithTraceState(ctx context.Context, state string) context.Context {
+	if state == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, traceStateKey{}, state)
+}
+
+func TraceState(ctx context.Context) string {
+	state, _ := ctx.Value(traceStateKey{}).(string)
+	return state
+}
+
+//Extract 先按tracer自身的格式提取，没有时再取W3C traceparent
+//两者都没有时返回tracer的错误，通常为opentracing.ErrSpanContextNotFound
+func Extract(t opentracing.Tracer, header http.Header) (opentracing.SpanContext, error) {
+	spanContext, err := t.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header))
+	if err == nil {
+		return spanContext, nil
+	}
+
+	traceID, spanID, sampled, ok := parseTraceParent(header.Get(TraceParentHeader))
+	if !ok {
+		return nil, err
+	}
+	flags := "0"
+	if sampled {
+		flags = "1"
+	}
+	carrier := opentracing.TextMapCarrier{uberTraceIDKey: traceID + ":" + spanID + ":0:" + flags}
+	return t.Extract(opentracing.TextMap, carrier)
+}
+
+//Inject 同时写入tracer自身的格式和W3C traceparent/tracestate
+func Inject(ctx context.Context, span opentracing.Span, header http.Header) error {
+	if err := span.Tracer().Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header)); err != nil {
+		return err
+	}
+
+	if parts := uberTraceID(span); parts != nil {
+		flags, _ := strconv.ParseUint(parts[3], 10, 8)
+		sampled := "00"
+		if flags&1 == 1 {
+			sampled = "01"
+		}
+		header.Set(TraceParentHeader, "00-"+leftPad(parts[0], 32)+"-"+leftPad(parts[1], 16)+"-"+sampled)
+	}
+	if state := TraceState(ctx); state != "" {
+		header.Set(TraceStateHeader, state)
+	}
+	return nil
+}
+
+//IDs 取span的trace id和span id，tracer未按jaeger格式传播时返回空字符串
+func IDs(span opentracing.Span) (traceID, spanID string) {
+	parts := uberTraceID(span)
+	if parts == nil {
+		return "", ""
+	}
+	return parts[0], parts[1]
+}
+
+//uberTraceID 通过Inject读取uber-trace-id，不依赖具体的tracer实现
+func uberTraceID(span opentracing.Span) []string {
+	carrier := opentracing.TextMapCarrier{}
+	if err := span.Tracer().Inject(span.Context(), opentracing.TextMap, carrier); err != nil {
+		return nil
+	}
+
+	value, err := url.QueryUnescape(carrier[uberTraceIDKey])
+	if err != nil {
+		return nil
+	}
+	parts := strings.Split(value, ":")
+	if len(parts) != 4 {
+		return nil
+	}
+	return parts
+}
+
+//parseTraceParent 解析 {version}-{trace-id}-{parent-id}-{flags}
+//version为ff、id全为0或长度不对时视为无效，高版本允许在flags之后有更多字段
+func parseTraceParent(value string) (traceID, spanID string, sampled, ok bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return "", "", false, false
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return "", "", false, false
+	}
+	if !isHex(traceID, 32) || !isHex(spanID, 16) || !isHex(flags, 2) {
+		return "", "", false, false
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return "", "", false, false
+	}
+
+	f, _ := strconv.ParseUint(flags, 16, 8)
+	return traceID, spanID, f&1 == 1, true
+}
+
+//isHex W3C要求小写十六进制
+func isHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func leftPad(s string, length int) string {
+	if len(s) >= length {
+		return s
+	}
+	return strings.Repeat("0", length-len(s)) + s
+}
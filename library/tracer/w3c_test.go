@@ -0,0 +1,119 @@
+package tracer
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+	cases := []struct {
+		name    string
+		value   string
+		ok      bool
+		sampled bool
+	}{
+		{"sampled", "00-" + traceID + "-" + spanID + "-01", true, true},
+		{"not sampled", "00-" + traceID + "-" + spanID + "-00", true, false},
+		{"surrounding spaces", " 00-" + traceID + "-" + spanID + "-01 ", true, true},
+		{"other flags", "00-" + traceID + "-" + spanID + "-03", true, true},
+		{"future version extra fields", "01-" + traceID + "-" + spanID + "-01-what-the-future", true, true},
+		{"version ff", "ff-" + traceID + "-" + spanID + "-01", false, false},
+		{"version 00 extra fields", "00-" + traceID + "-" + spanID + "-01-extra", false, false},
+		{"zero trace id", "00-00000000000000000000000000000000-" + spanID + "-01", false, false},
+		{"zero span id", "00-" + traceID + "-0000000000000000-01", false, false},
+		{"uppercase trace id", "00-4BF92F3577B34DA6A3CE929D0E0E4736-" + spanID + "-01", false, false},
+		{"uppercase version", "0A-" + traceID + "-" + spanID + "-01", false, false},
+		{"short trace id", "00-" + traceID[1:] + "-" + spanID + "-01", false, false},
+		{"short span id", "00-" + traceID + "-" + spanID[1:] + "-01", false, false},
+		{"bad flags", "00-" + traceID + "-" + spanID + "-0g", false, false},
+		{"missing flags", "00-" + traceID + "-" + spanID, false, false},
+		{"empty", "", false, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gotTrace, gotSpan, sampled, ok := parseTraceParent(c.value)
+			if ok != c.ok {
+				t.Fatalf("parseTraceParent(%q) ok = %v, want %v", c.value, ok, c.ok)
+			}
+			if !ok {
+				return
+			}
+			if gotTrace != traceID || gotSpan != spanID || sampled != c.sampled {
+				t.Errorf("parseTraceParent(%q) = %s, %s, %v", c.value, gotTrace, gotSpan, sampled)
+			}
+		})
+	}
+}
+
+func TestTraceParentRoundTrip(t *testing.T) {
+	for _, sampled := range []bool{true, false} {
+		tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(sampled), jaeger.NewNullReporter())
+		defer closer.Close()
+
+		span := tracer.StartSpan("client")
+		ctx := ContextWithTraceState(context.Background(), "vendor=value")
+
+		header := http.Header{}
+		if err := Inject(ctx, span, header); err != nil {
+			t.Fatalf("Inject: %v", err)
+		}
+		if got := header.Get(TraceStateHeader); got != "vendor=value" {
+			t.Errorf("tracestate = %q", got)
+		}
+
+		//下游只认识W3C时只会带上traceparent
+		w3c := http.Header{}
+		w3c.Set(TraceParentHeader, header.Get(TraceParentHeader))
+		spanContext, err := Extract(tracer, w3c)
+		if err != nil {
+			t.Fatalf("Extract(%q): %v", w3c.Get(TraceParentHeader), err)
+		}
+
+		parent := span.Context().(jaeger.SpanContext)
+		got := spanContext.(jaeger.SpanContext)
+		if got.TraceID() != parent.TraceID() || got.SpanID() != parent.SpanID() {
+			t.Errorf("round trip = %s:%s, want %s:%s", got.TraceID(), got.SpanID(), parent.TraceID(), parent.SpanID())
+		}
+		if got.IsSampled() != sampled {
+			t.Errorf("sampled = %v, want %v", got.IsSampled(), sampled)
+		}
+
+		child := tracer.StartSpan("server", opentracing.ChildOf(spanContext))
+		childTrace, _ := IDs(child)
+		parentTrace, _ := IDs(span)
+		if childTrace != parentTrace {
+			t.Errorf("child trace id = %s, want %s", childTrace, parentTrace)
+		}
+		child.Finish()
+		span.Finish()
+	}
+}
+
+func TestExtractPrefersTracerFormat(t *testing.T) {
+	tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+	defer closer.Close()
+
+	span := tracer.StartSpan("client")
+	defer span.Finish()
+	header := http.Header{}
+	if err := tracer.Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header)); err != nil {
+		t.Fatal(err)
+	}
+	header.Set(TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	spanContext, err := Extract(tracer, header)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if got := spanContext.(jaeger.SpanContext).TraceID(); got != span.Context().(jaeger.SpanContext).TraceID() {
+		t.Errorf("trace id = %s, want the uber-trace-id one", got)
+	}
+}
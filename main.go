@@ -1,15 +1,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
 	"runtime"
 	"strconv"
 	"syscall"
 
+	"gin-frame/bootstrap"
 	"gin-frame/routers"
+	"gin-frame/shutdown"
 
-	"github.com/why444216978/go-library/libraries/config"
 	"github.com/why444216978/go-library/libraries/endless"
 	"github.com/why444216978/go-library/libraries/util/error"
 )
@@ -26,22 +29,38 @@ func init() {
 }
 
 func main() {
-	appSection := "app"
-	appConfig := config.GetConfig("app", appSection)
-	port, err := appConfig.Key("port").Int()
+	//配置有误时列出全部问题后退出，不会等到请求时才发现
+	cfg, err := bootstrap.LoadConfig()
 	error.Must(err)
-	env = appConfig.Key("env").String()
-	productName = appConfig.Key("product").String()
-	moduleName = appConfig.Key("module").String()
+	port = cfg.App.Port
+	env = cfg.App.Env
+	productName = cfg.App.Product
+	moduleName = cfg.App.Module
 
-	server := routers.InitRouter(port, productName, moduleName, env)
+	c, err := bootstrap.NewContainer(cfg)
+	error.Must(err)
+
+	server := routers.InitRouter(port, productName, moduleName, env, c)
 
 	tmpServer := endless.NewServer(fmt.Sprintf(":%s", strconv.Itoa(port)), server)
 	tmpServer.BeforeBegin = func(add string) {
 		log.Printf("Actual pid is %d", syscall.Getpid())
 	}
+
+	//收到重启/退出信号后readiness立即返回未就绪，负载均衡摘除流量后再关闭
+	var coordinator *shutdown.Coordinator
+	c.MustResolve(&coordinator)
+	for _, sig := range []os.Signal{syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM} {
+		tmpServer.SignalHooks[endless.PRE_SIGNAL][sig] = append(tmpServer.SignalHooks[endless.PRE_SIGNAL][sig], coordinator.BeginDrain)
+	}
+
 	err = tmpServer.ListenAndServe()
 	if err != nil {
 		log.Printf("Server err: %v", err)
 	}
+
+	//endless停止接收新连接后ListenAndServe返回，释放全部资源
+	if err := coordinator.Shutdown(context.Background()); err != nil {
+		log.Printf("Shutdown err: %v", err)
+	}
 }
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"gin-frame/codes"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	customerHeader = "X-Customer-Id"
+	//CustomerIdKey 鉴权通过后情报员ID保存在gin.Context中的key
+	CustomerIdKey = "customer_id"
+)
+
+//SpyAuth 情报员鉴权中间件，在controller之前执行
+//请求头缺少情报员ID返回ERRNO_MISS_ORIGIN_CUSTOMER_ID，不是情报员返回NO_AUTHORIZE_SPY，数据源异常返回SERVER_ERROR
+func SpyAuth(store SpyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		customerId, err := strconv.Atoi(c.GetHeader(customerHeader))
+		if err != nil || customerId <= 0 {
+			abort(c, codes.New(codes.ERRNO_MISS_ORIGIN_CUSTOMER_ID))
+			return
+		}
+
+		isSpy, err := store.IsSpy(c.Request.Context(), customerId)
+		if err != nil {
+			abort(c, codes.Wrap(codes.SERVER_ERROR, err))
+			return
+		}
+		if !isSpy {
+			abort(c, codes.New(codes.NO_AUTHORIZE_SPY))
+			return
+		}
+
+		c.Set(CustomerIdKey, customerId)
+		c.Next()
+	}
+}
+
+func abort(c *gin.Context, e *codes.Error) {
+	if e.Cause != nil {
+		_ = c.Error(e)
+	}
+	c.Set(codes.ErrnoKey, e.Errno)
+	c.AbortWithStatusJSON(e.HttpStatus, gin.H{
+		"errno":    e.Errno,
+		"errmsg":   e.Msg,
+		"data":     make(map[string]interface{}),
+		"user_msg": e.UserMsg,
+	})
+}
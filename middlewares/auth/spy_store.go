@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gin-frame/config"
+	"gin-frame/library/redis_client"
+	"gin-frame/models/base"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/why444216978/go-library/libraries/mysql"
+)
+
+//SpyStore 情报员身份数据源
+type SpyStore interface {
+	IsSpy(ctx context.Context, customerId int) (bool, error)
+}
+
+//MysqlSpyStore 情报员表中存在且status为1的用户是情报员
+type MysqlSpyStore struct {
+	db    *mysql.DB
+	table string
+}
+
+func NewMysqlSpyStore(db *mysql.DB, table string) *MysqlSpyStore {
+	return &MysqlSpyStore{db: db, table: table}
+}
+
+func (store *MysqlSpyStore) IsSpy(ctx context.Context, customerId int) (bool, error) {
+	count := 0
+	orm := base.WithContext(ctx, store.db.SlaveOrm())
+	dbRes := orm.Table(store.table).Where("customer_id = ? AND status = ?", customerId, 1).Count(&count)
+	if err := base.CheckRes("IsSpy", store.table, dbRes); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+//RedisSpyStore 情报员ID保存在redis set中
+type RedisSpyStore struct {
+	redis *redis_client.Client
+	key   string
+}
+
+func NewRedisSpyStore(db *redis_client.Client, key string) *RedisSpyStore {
+	return &RedisSpyStore{redis: db, key: key}
+}
+
+func (store *RedisSpyStore) IsSpy(ctx context.Context, customerId int) (bool, error) {
+	ok, err := redigo.Bool(store.redis.Do(ctx, "SISMEMBER", store.key, customerId))
+	if err != nil {
+		return false, fmt.Errorf("sismember %s: %w", store.key, err)
+	}
+	return ok, nil
+}
+
+type spyDecision struct {
+	isSpy    bool
+	expireAt time.Time
+}
+
+//CachedSpyStore 在进程内缓存鉴权结果，情报员和非情报员使用不同的过期时间
+type CachedSpyStore struct {
+	store       SpyStore
+	ttl         time.Duration
+	negativeTtl time.Duration
+	maxEntries  int
+
+	lock      sync.RWMutex
+	decisions map[int]spyDecision
+}
+
+func NewCachedSpyStore(store SpyStore, ttl, negativeTtl time.Duration, maxEntries int) *CachedSpyStore {
+	return &CachedSpyStore{
+		store:       store,
+		ttl:         ttl,
+		negativeTtl: negativeTtl,
+		maxEntries:  maxEntries,
+		decisions:   make(map[int]spyDecision),
+	}
+}
+
+func (cache *CachedSpyStore) IsSpy(ctx context.Context, customerId int) (bool, error) {
+	now := time.Now()
+
+	cache.lock.RLock()
+	decision, ok := cache.decisions[customerId]
+	cache.lock.RUnlock()
+	if ok && now.Before(decision.expireAt) {
+		return decision.isSpy, nil
+	}
+
+	isSpy, err := cache.store.IsSpy(ctx, customerId)
+	if err != nil {
+		return false, err
+	}
+
+	ttl := cache.ttl
+	if !isSpy {
+		ttl = cache.negativeTtl
+	}
+	if ttl <= 0 {
+		return isSpy, nil
+	}
+
+	cache.lock.Lock()
+	if len(cache.decisions) >= cache.maxEntries {
+		cache.evict(now)
+	}
+	cache.decisions[customerId] = spyDecision{isSpy: isSpy, expireAt: now.Add(ttl)}
+	cache.lock.Unlock()
+
+	return isSpy, nil
+}
+
+//evict 清理过期的结果，仍然超过上限时全部清空
+func (cache *CachedSpyStore) evict(now time.Time) {
+	for customerId, decision := range cache.decisions {
+		if !now.Before(decision.expireAt) {
+			delete(cache.decisions, customerId)
+		}
+	}
+	if len(cache.decisions) >= cache.maxEntries {
+		cache.decisions = make(map[int]spyDecision)
+	}
+}
+
+//NewSpyStore 按app.ini的[spy_auth]创建带缓存的SpyStore
+//	store = mysql 时 conn为mysql.ini中的连接名，table为情报员表
+//	store = redis 时 conn为redis.ini中的section，key为情报员set
+func NewSpyStore(cfg *config.Config, registry *redis_client.Registry) (SpyStore, error) {
+	spyCfg := cfg.SpyAuth
+
+	var store SpyStore
+	switch spyCfg.Store {
+	case "mysql":
+		db, err := base.GetInstance(spyCfg.Conn)
+		if err != nil {
+			return nil, err
+		}
+		store = NewMysqlSpyStore(db, spyCfg.Table)
+	case "redis":
+		db, err := registry.Get(spyCfg.Conn)
+		if err != nil {
+			return nil, err
+		}
+		store = NewRedisSpyStore(db, spyCfg.Key)
+	default:
+		return nil, fmt.Errorf("spy_auth: unknown store %q", spyCfg.Store)
+	}
+
+	return NewCachedSpyStore(store, spyCfg.CacheTtl, spyCfg.NegativeTtl, spyCfg.CacheSize), nil
+}
@@ -0,0 +1,160 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gin-frame/library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+const truncatedMark = "...[truncated]"
+
+//BodyOptions 请求/响应体的记录方式
+type BodyOptions struct {
+	//MaxBody 记录的请求体和响应体最大字节数，超过部分截断，<=0时不记录body
+	MaxBody int
+	//SkipRoutes 不记录body的路由模板，如 /origin/prices
+	SkipRoutes map[string]bool
+	Redactor   *logger.Redactor
+}
+
+func (opts *BodyOptions) skip(c *gin.Context) bool {
+	return opts.MaxBody <= 0 || opts.SkipRoutes[c.FullPath()]
+}
+
+//captureRequest 只读取MaxBody+1个字节用于记录，剩余部分原样留给后续handler读取
+func captureRequest(c *gin.Context, opts *BodyOptions) interface{} {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil
+	}
+	if opts.skip(c) {
+		return skippedMark("route")
+	}
+	contentType := c.GetHeader("Content-Type")
+	if !isText(contentType) {
+		return skippedMark(contentType)
+	}
+
+	captured, err := ioutil.ReadAll(io.LimitReader(c.Request.Body, int64(opts.MaxBody)+1))
+	c.Request.Body = &readCloser{
+		Reader: io.MultiReader(bytes.NewReader(captured), c.Request.Body),
+		Closer: c.Request.Body,
+	}
+	if err != nil {
+		return fmt.Sprintf("[read error: %v]", err)
+	}
+	return decodeBody(captured, contentType, opts)
+}
+
+//responseWriter 记录最多MaxBody个字节的响应体，其余部分只写给客户端
+type responseWriter struct {
+	gin.ResponseWriter
+	body  *bytes.Buffer
+	limit int
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+//capture 多保留一个字节用于判断是否截断
+func (w *responseWriter) capture(b []byte) {
+	if room := w.limit + 1 - w.body.Len(); room > 0 {
+		if len(b) > room {
+			b = b[:room]
+		}
+		w.body.Write(b)
+	}
+}
+
+func captureResponse(c *gin.Context, opts *BodyOptions) *responseWriter {
+	if opts.skip(c) {
+		return nil
+	}
+	w := &responseWriter{ResponseWriter: c.Writer, body: bytes.NewBuffer(nil), limit: opts.MaxBody}
+	c.Writer = w
+	return w
+}
+
+func (w *responseWriter) result(opts *BodyOptions) interface{} {
+	if w == nil {
+		return skippedMark("route")
+	}
+	contentType := w.Header().Get("Content-Type")
+	if !isText(contentType) {
+		return skippedMark(contentType)
+	}
+	return decodeBody(w.body.Bytes(), contentType, opts)
+}
+
+//decodeBody 完整的json和form解码后脱敏，截断或无法解码的按字段名匹配脱敏后按字符串记录
+func decodeBody(body []byte, contentType string, opts *BodyOptions) interface{} {
+	if len(body) == 0 {
+		return nil
+	}
+	if len(body) > opts.MaxBody {
+		return opts.Redactor.Text(string(body[:opts.MaxBody])) + truncatedMark
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			return opts.Redactor.Value(map[string][]string(values))
+		}
+	case isJSON(mediaType):
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err == nil {
+			return opts.Redactor.Value(v)
+		}
+	}
+	return opts.Redactor.Text(string(body))
+}
+
+//isText 文本类的body才记录，没有Content-Type时按文本处理
+func isText(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		isJSON(mediaType),
+		mediaType == "application/x-www-form-urlencoded",
+		mediaType == "application/xml",
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	return false
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func skippedMark(reason string) string {
+	return "[skipped: " + reason + "]"
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,61 @@
+package log
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gin-frame/library/logger"
+)
+
+func TestDecodeBody(t *testing.T) {
+	opts := &BodyOptions{
+		MaxBody:  40,
+		Redactor: logger.NewRedactor(nil, []string{"password", "token"}, true),
+	}
+	cases := []struct {
+		name        string
+		body        string
+		contentType string
+		want        interface{}
+	}{
+		{
+			name:        "json",
+			body:        `{"user":{"password":"secret"}}`,
+			contentType: "application/json",
+			want:        map[string]interface{}{"user": map[string]interface{}{"password": logger.RedactedMark}},
+		},
+		{
+			name:        "truncated json",
+			body:        `{"name":"abcdefghij","password":"secret-secret"}`,
+			contentType: "application/json; charset=utf-8",
+			want:        `{"name":"abcdefghij","password":"[REDACTED]"` + truncatedMark,
+		},
+		{
+			name:        "form",
+			body:        "token=abc&name=a",
+			contentType: "application/x-www-form-urlencoded",
+			want:        map[string]interface{}{"token": logger.RedactedMark, "name": []string{"a"}},
+		},
+		{
+			name:        "truncated form",
+			body:        "name=" + strings.Repeat("a", 20) + "&password=secret-secret-secret",
+			contentType: "application/x-www-form-urlencoded",
+			want:        "name=" + strings.Repeat("a", 20) + "&password=[REDACTED]" + truncatedMark,
+		},
+		{
+			name:        "invalid json",
+			body:        `{"password":"secret"`,
+			contentType: "application/json",
+			want:        `{"password":"[REDACTED]"`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := decodeBody([]byte(c.body), c.contentType, opts)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("decodeBody() = %#v, want %#v", got, c.want)
+			}
+		})
+	}
+}
@@ -1,105 +1,59 @@
 package log
 
 import (
-	"bytes"
-	"github.com/why444216978/go-library/libraries/log"
-	"github.com/why444216978/go-library/libraries/util/conversion"
-	"github.com/why444216978/go-library/libraries/util/dir"
-	"github.com/why444216978/go-library/libraries/util/random"
-	"github.com/why444216978/go-library/libraries/util/sys"
+	"gin-frame/library/logger"
+	"gin-frame/middlewares/request"
 	"github.com/why444216978/go-library/libraries/util/url"
-	"github.com/why444216978/go-library/libraries/xhop"
 	"github.com/gin-gonic/gin"
-	"io/ioutil"
 	"net/http"
-	"strconv"
 	"time"
 )
 
-//定义新的struck，继承gin的ResponseWriter
-//添加body字段，用于将response暴露给日志
-type bodyLogWriter struct {
-	gin.ResponseWriter
-	body *bytes.Buffer
-}
-
-//gin的ResponseWriter继承的底层http server
-//实现http的Write方法，额外添加一个body字段，用于获取response body
-func (w bodyLogWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
-	return w.ResponseWriter.Write(b)
-}
-
-func LoggerMiddleware(port int, logFields map[string]string, runLogDir string, logArea int, productName, moduleName, env string) gin.HandlerFunc {
+//LoggerMiddleware 记录每个请求，按http状态码选择日志级别：2xx/3xx为info，4xx为warn，5xx为error
+//耗时超过slowThreshold的请求至少以warn记录，slowThreshold<=0时不检查
+//请求头、url参数和body按bodyOptions脱敏，body超过MaxBody时截断
+//log_id、x-hop、trace id和开始时间取自request.RequestContext，需要放在它之后
+func LoggerMiddleware(runLogger *logger.Logger, slowThreshold time.Duration, bodyOptions *BodyOptions) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		logHeader := &log.LogFormat{}
-
-		file := dir.CreateHourLogFile(runLogDir, moduleName+".log."+sys.HostName()+".")
-		file = file + "/" + strconv.Itoa(random.RandomN(logArea))
-
-		log.Init(&log.LogConfig{
-			File:           file,
-			Path:           runLogDir,
-			Mode:           1,
-			AsyncFormatter: false,
-			Debug:          true,
-		}, runLogDir, file)
-
-		var logID string
-		switch {
-		case c.Query(logFields["query_id"]) != "":
-			logID = c.Query(logFields["query_id"])
-		case c.Request.Header.Get(logFields["header_id"]) != "":
-			logID = c.Request.Header.Get(logFields["header_id"])
-		default:
-			logID = log.NewObjectId().Hex()
-		}
-
-		ctx := c.Request.Context()
-		dst := new(log.LogFormat)
-		*dst = *logHeader
-
-		dst.Port = port
-		dst.LogId = logID
-		dst.Method = c.Request.Method
-		dst.CallerIp = c.ClientIP()
-		dst.UriPath = c.Request.RequestURI
-		dst.XHop = xhop.NextXhop(c.Request.Header, logFields["header_hop"])
-		dst.Product = productName
-		dst.Module = moduleName
-
-		dst.Env = env
-
-		ctx = log.ContextWithLogHeader(ctx, dst)
-		c.Request = c.Request.WithContext(ctx)
-		c.Writer.Header().Set(logFields["header_id"], dst.LogId)
-		c.Writer.Header().Set(logFields["header_hop"], dst.XHop.String())
-
-		reqBody := []byte{}
-		if c.Request.Body != nil { // Read
-			reqBody, _ = ioutil.ReadAll(c.Request.Body)
+		rc := request.FromGin(c)
+		if rc == nil {
+			c.Next()
+			return
 		}
-		strReqBody := string(reqBody)
 
-		c.Request.Body = ioutil.NopCloser(bytes.NewBuffer(reqBody)) // Reset
-		responseWriter := &bodyLogWriter{body: bytes.NewBuffer(nil), ResponseWriter: c.Writer}
-		c.Writer = responseWriter
-
-		dst.StartTime = time.Now()
+		rc.RequestBody = captureRequest(c, bodyOptions)
+		responseWriter := captureResponse(c, bodyOptions)
 
 		c.Next() // 处理请求
 
-		dst.HttpCode = c.Writer.Status()
+		latency := rc.Latency()
+		dst := rc.LogFormat(c.Writer.Status())
 
-		responseBody := responseWriter.body.String()
+		responseSize := c.Writer.Size()
+		if responseSize < 0 {
+			responseSize = 0
+		}
+		slow := slowThreshold > 0 && latency > slowThreshold
+
+		fields := map[string]interface{}{
+			"requestHeader": bodyOptions.Redactor.Header(c.Request.Header),
+			"requestBody":   rc.RequestBody,
+			"responseBody":  responseWriter.result(bodyOptions),
+			"uriQuery":      bodyOptions.Redactor.Value(url.ParseUriQueryToMap(c.Request.URL.RawQuery)),
+			"trace_id":      rc.TraceId,
+			"span_id":       rc.SpanId,
+			"latency_ms":    float64(latency.Microseconds()) / 1000,
+			"response_size": responseSize,
+			"slow":          slow,
+		}
 
-		if dst.HttpCode == http.StatusOK {
-			log.Info(dst, map[string]interface{}{
-				"requestHeader": c.Request.Header,
-				"requestBody":   conversion.JsonToMap(strReqBody),
-				"responseBody":  conversion.JsonToMap(responseBody),
-				"uriQuery":      url.ParseUriQueryToMap(c.Request.URL.RawQuery),
-			})
+		switch {
+		case dst.HttpCode >= http.StatusInternalServerError:
+			runLogger.Error(dst, fields)
+		case dst.HttpCode >= http.StatusBadRequest || slow:
+			runLogger.Warn(dst, fields)
+		default:
+			runLogger.Info(dst, fields)
 		}
 	}
 }
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"gin-frame/codes"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+//unmatchedRoute 没有匹配到路由的请求统一使用的route标签，避免按原始路径产生无限多的时间序列
+const unmatchedRoute = "unmatched"
+
+var labels = []string{"route", "method", "code", "errno"}
+
+var (
+	requestTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP请求数",
+	}, labels)
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP请求耗时",
+		Buckets: prometheus.DefBuckets,
+	}, labels)
+
+	responseSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_response_size_bytes",
+		Help:    "HTTP响应体大小",
+		Buckets: prometheus.ExponentialBuckets(100, 10, 6),
+	}, labels)
+)
+
+func init() {
+	prometheus.MustRegister(requestTotal, requestDuration, responseSize)
+}
+
+//Metrics 按路由模板、method、http状态码和errno统计请求数、耗时和响应大小
+//errno取controller或中间件保存在gin.Context中的codes.ErrnoKey，没有输出errno的请求为空
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		errno := ""
+		if v, ok := c.Get(codes.ErrnoKey); ok {
+			if n, ok := v.(int); ok {
+				errno = strconv.Itoa(n)
+			}
+		}
+		size := c.Writer.Size()
+		if size < 0 {
+			size = 0
+		}
+
+		values := []string{route, c.Request.Method, strconv.Itoa(c.Writer.Status()), errno}
+		requestTotal.WithLabelValues(values...).Inc()
+		requestDuration.WithLabelValues(values...).Observe(time.Since(start).Seconds())
+		responseSize.WithLabelValues(values...).Observe(float64(size))
+	}
+}
+
+//Handler 以prometheus文本格式输出默认registry中的全部指标
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
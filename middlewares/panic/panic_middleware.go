@@ -1,118 +1,101 @@
 package panic
 
 import (
-	"bytes"
+	"fmt"
 	"gin-frame/codes"
+	"gin-frame/library/alert"
+	"gin-frame/library/logger"
+	"gin-frame/middlewares/request"
 	"github.com/why444216978/go-library/libraries/log"
-	"github.com/why444216978/go-library/libraries/util"
-	"github.com/why444216978/go-library/libraries/util/conversion"
 	"github.com/why444216978/go-library/libraries/util/url"
-	"github.com/why444216978/go-library/libraries/xhop"
 	"github.com/gin-gonic/gin"
-	"io/ioutil"
-	"net/http"
 	"runtime/debug"
-	"strconv"
 	"strings"
 	"time"
 )
 
-type bodyLogWriter struct {
-	gin.ResponseWriter
-	body *bytes.Buffer
-}
-
-func (w bodyLogWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
-	return w.ResponseWriter.Write(b)
-}
+//ThrowPanic 捕获panic，输出SERVER_ERROR并记录错误日志
+//log_id、x-hop和开始时间取自request.RequestContext，与运行日志中的同一请求一致
+//同一签名的panic在dedupWindow内只记录一次完整的栈并告警一次，之后只记录签名和累计次数
+//返回的HandlerFunc可以注册在多个位置，共用同一个去重状态，已被内层recover的panic不会再到达外层
+func ThrowPanic(errorLogger *logger.Logger, redactor *logger.Redactor, notifier *alert.Notifier, dedupWindow time.Duration) gin.HandlerFunc {
+	seen := newDedup(dedupWindow)
 
-func ThrowPanic(port int, logFields map[string]string, dir string, area int, productName, moduleName, env string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func(c *gin.Context) {
 			if err := recover(); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"errno":    codes.SERVER_ERROR,
-					"errmsg":   codes.ErrorMsg[codes.SERVER_ERROR],
-					"data":     make(map[string]interface{}),
-					"user_msg": codes.ErrorUserMsg[codes.SERVER_ERROR],
-				})
-
-				debugStack := make(map[int]interface{})
-				for k, v := range strings.Split(string(debug.Stack()), "\n") {
-					debugStack[k] = v
+				sig := signature(err)
+				first, count := seen.observe(sig, time.Now())
+
+				//gin的Next在recover之后会继续执行后面的handler，必须Abort，否则controller仍会执行并再次输出
+				c.Abort()
+
+				//handler在panic之前已经输出时不再追加响应，只记录当时的状态
+				written := c.Writer.Written()
+				serverErr := codes.New(codes.SERVER_ERROR)
+				c.Set(codes.ErrnoKey, serverErr.Errno)
+				var response interface{} = "[written before panic]"
+				if !written {
+					response = gin.H{
+						"errno":    serverErr.Errno,
+						"errmsg":   serverErr.Msg,
+						"data":     make(map[string]interface{}),
+						"user_msg": serverErr.UserMsg,
+					}
+					c.JSON(serverErr.HttpStatus, response)
 				}
 
-				file := util.CreateDateDir(dir, moduleName+".err."+util.HostName()+".")
-				file = file + "/" + strconv.Itoa(util.RandomN(area))
-
-				log.Init(&log.LogConfig{
-					File:           file,
-					Path:           dir,
-					Mode:           1,
-					AsyncFormatter: false,
-					Debug:          true,
-				}, dir, file)
-
-				var logID string
-				switch {
-				case c.Query(logFields["query_id"]) != "":
-					logID = c.Query(logFields["query_id"])
-				case c.Request.Header.Get(logFields["header_id"]) != "":
-					logID = c.Request.Header.Get(logFields["header_id"])
-				default:
-					logID = log.NewObjectId().Hex()
+				fields := map[string]interface{}{
+					"requestHeader":    redactor.Header(c.Request.Header),
+					"responseBody":     response,
+					"response_written": written,
+					"response_size":    c.Writer.Size(),
+					"uriQuery":         redactor.Value(url.ParseUriQueryToMap(c.Request.URL.RawQuery)),
+					"err":              fmt.Sprintf("%v", err),
+					"panic_type":       fmt.Sprintf("%T", err),
+					"panic_signature":  sig,
+					"panic_count":      count,
 				}
 
-				logHeader := &log.LogFormat{}
-				ctx := c.Request.Context()
-				dst := new(log.LogFormat)
-				*dst = *logHeader
-
-				dst.Port = port
-				dst.LogId = logID
-				dst.Method = c.Request.Method
-				dst.CallerIp = c.ClientIP()
-				dst.UriPath = c.Request.RequestURI
-				dst.XHop = xhop.NextXhop(c.Request.Header, logFields["header_hop"])
-				dst.Product = productName
-				dst.Module = moduleName
-				dst.Env = env
-
-				ctx = log.ContextWithLogHeader(ctx, dst)
-				c.Request = c.Request.WithContext(ctx)
-				c.Writer.Header().Set(logFields["header_id"], dst.LogId)
-				c.Writer.Header().Set(logFields["header_hop"], dst.XHop.String())
-
-				reqBody := []byte{}
-				if c.Request.Body != nil { // Read
-					reqBody, _ = ioutil.ReadAll(c.Request.Body)
+				stack := string(debug.Stack())
+				if first {
+					debugStack := make(map[int]interface{})
+					for k, v := range strings.Split(stack, "\n") {
+						debugStack[k] = v
+					}
+					fields["trace"] = debugStack
 				}
-				strReqBody := string(reqBody)
-
-				c.Request.Body = ioutil.NopCloser(bytes.NewBuffer(reqBody)) // Reset
-				responseWriter := &bodyLogWriter{body: bytes.NewBuffer(nil), ResponseWriter: c.Writer}
-				c.Writer = responseWriter
-
-				dst.StartTime = time.Now()
-
-				dst.HttpCode = c.Writer.Status()
 
-				responseBody := responseWriter.body.String()
-
-				log.Error(dst, map[string]interface{}{
-					"requestHeader": c.Request.Header,
-					"requestBody":   conversion.JsonToMap(strReqBody),
-					"responseBody":  conversion.JsonToMap(responseBody),
-					"uriQuery":      url.ParseUriQueryToMap(c.Request.URL.RawQuery),
-					"err":           err,
-					"trace":         debugStack,
-				})
+				var dst *log.LogFormat
+				if rc := request.FromGin(c); rc != nil {
+					dst = rc.LogFormat(c.Writer.Status())
+					fields["requestBody"] = rc.RequestBody
+					fields["trace_id"] = rc.TraceId
+					fields["span_id"] = rc.SpanId
+					fields["latency_ms"] = float64(rc.Latency().Microseconds()) / 1000
+				}
 
-				/* util.WriteWithIo(file,"[" +dateTime+"]")
-				util.WriteWithIo(file, fmt.Sprintf("%v\r\n", err))
-				util.WriteWithIo(file, debugStack) */
-				c.Done()
+				errorLogger.Error(dst, fields)
+
+				if first {
+					a := &alert.Alert{
+						Title:     "panic",
+						Method:    c.Request.Method,
+						UriPath:   c.Request.URL.Path,
+						PanicType: fmt.Sprintf("%T", err),
+						Message:   fmt.Sprintf("%v", err),
+						Signature: sig,
+						Stack:     stack,
+						Time:      time.Now(),
+					}
+					if dst != nil {
+						a.Product = dst.Product
+						a.Module = dst.Module
+						a.Env = dst.Env
+						a.LogId = dst.LogId
+					}
+					notifier.Notify(a)
+				}
 			}
 		}(c)
 		c.Next()
@@ -0,0 +1,41 @@
+package panic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gin-frame/config"
+	"gin-frame/library/alert"
+	"gin-frame/library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestThrowPanicAborts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	errorLogger := logger.New(&config.LogFile{Dir: t.TempDir(), Area: 1, Rotate: "day", Buffer: 10, FlushInterval: time.Second}, "error.")
+	recovery := ThrowPanic(errorLogger, logger.NewRedactor(nil, nil, false), alert.New(nil, 0, time.Second), time.Minute)
+
+	handled := 0
+	server := gin.New()
+	server.Use(recovery)
+	server.Use(func(c *gin.Context) {
+		panic("middleware")
+	})
+	server.GET("/", func(c *gin.Context) {
+		handled++
+		c.String(http.StatusOK, "ok")
+	})
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if handled != 0 {
+		t.Errorf("handler ran %d times after panic", handled)
+	}
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", w.Code)
+	}
+}
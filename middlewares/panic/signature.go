@@ -0,0 +1,77 @@
+package panic
+
+import (
+	"fmt"
+	"hash/fnv"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+//maxSignatureFrames 计算签名使用的panic位置之后的栈帧数
+const maxSignatureFrames = 10
+
+//signature panic值类型加panic位置的调用栈，同一处代码的panic签名相同，不受参数、goroutine id影响
+//需要在recover所在的defer函数中调用
+func signature(value interface{}) string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%T", value)
+
+	afterPanic := false
+	count := 0
+	for {
+		frame, more := frames.Next()
+		switch {
+		case frame.Function == "runtime.gopanic":
+			afterPanic = true
+		case afterPanic && !strings.HasPrefix(frame.Function, "runtime."):
+			fmt.Fprintf(h, "|%s:%d", frame.Function, frame.Line)
+			count++
+		}
+		if !more || count >= maxSignatureFrames {
+			break
+		}
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+type occurrence struct {
+	first time.Time
+	count int
+}
+
+//dedup 记录window内出现过的签名，同一签名只有第一次需要完整的栈和告警
+type dedup struct {
+	window time.Duration
+
+	lock sync.Mutex
+	seen map[string]*occurrence
+}
+
+func newDedup(window time.Duration) *dedup {
+	return &dedup{window: window, seen: make(map[string]*occurrence)}
+}
+
+//observe 返回是否为window内第一次出现，以及window内的累计次数
+func (d *dedup) observe(sig string, now time.Time) (bool, int) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for k, o := range d.seen {
+		if now.Sub(o.first) > d.window {
+			delete(d.seen, k)
+		}
+	}
+
+	if o, ok := d.seen[sig]; ok {
+		o.count++
+		return false, o.count
+	}
+	d.seen[sig] = &occurrence{first: now, count: 1}
+	return true, 1
+}
@@ -0,0 +1,110 @@
+package request
+
+import (
+	"context"
+	"time"
+
+	"gin-frame/library/tracer"
+
+	"github.com/gin-gonic/gin"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/why444216978/go-library/libraries/log"
+	"github.com/why444216978/go-library/libraries/xhop"
+)
+
+//contextKey gin.Context中保存*Context的key
+const contextKey = "request_context"
+
+type ctxKey struct{}
+
+//Context 一个请求只计算一次的公共信息，log、panic中间件和controller共用
+type Context struct {
+	LogId     string
+	XHop      *xhop.XHop
+	TraceId   string
+	SpanId    string
+	StartTime time.Time
+
+	//RequestBody log中间件记录的请求体，panic日志复用，避免再次读取已经被消费的body
+	RequestBody interface{}
+
+	header log.LogFormat
+}
+
+//LogFormat 返回日志头的副本，各中间件设置自己的HttpCode互不影响
+func (rc *Context) LogFormat(httpCode int) *log.LogFormat {
+	header := rc.header
+	header.HttpCode = httpCode
+	return &header
+}
+
+//Latency 从进入RequestContext到现在的耗时
+func (rc *Context) Latency() time.Duration {
+	return time.Since(rc.StartTime)
+}
+
+//RequestContext 计算log_id、x-hop、trace id和开始时间，保存到gin.Context和request context
+//log_id依次取url参数query_id、请求头header_id，都没有时生成新的ID，并写回响应头
+//需要放在OpenTracing之后、log和panic中间件之前
+func RequestContext(port int, logFields map[string]string, productName, moduleName, env string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		var logID string
+		switch {
+		case c.Query(logFields["query_id"]) != "":
+			logID = c.Query(logFields["query_id"])
+		case c.Request.Header.Get(logFields["header_id"]) != "":
+			logID = c.Request.Header.Get(logFields["header_id"])
+		default:
+			logID = log.NewObjectId().Hex()
+		}
+
+		rc := &Context{
+			LogId:     logID,
+			XHop:      xhop.NextXhop(c.Request.Header, logFields["header_hop"]),
+			StartTime: start,
+		}
+		if span := opentracing.SpanFromContext(c.Request.Context()); span != nil {
+			rc.TraceId, rc.SpanId = tracer.IDs(span)
+		}
+		rc.header = log.LogFormat{
+			LogId:     rc.LogId,
+			Method:    c.Request.Method,
+			CallerIp:  c.ClientIP(),
+			UriPath:   c.Request.RequestURI,
+			Port:      port,
+			XHop:      rc.XHop,
+			Product:   productName,
+			Module:    moduleName,
+			Env:       env,
+			StartTime: start,
+		}
+
+		ctx := log.ContextWithLogHeader(c.Request.Context(), rc.LogFormat(0))
+		ctx = context.WithValue(ctx, ctxKey{}, rc)
+		c.Request = c.Request.WithContext(ctx)
+		c.Set(contextKey, rc)
+
+		c.Writer.Header().Set(logFields["header_id"], rc.LogId)
+		c.Writer.Header().Set(logFields["header_hop"], rc.XHop.String())
+
+		c.Next()
+	}
+}
+
+//FromGin 没有经过RequestContext的请求返回nil
+func FromGin(c *gin.Context) *Context {
+	if v, ok := c.Get(contextKey); ok {
+		if rc, ok := v.(*Context); ok {
+			return rc
+		}
+	}
+	return nil
+}
+
+//FromContext 从request context中取*Context，service等只持有context.Context的地方使用
+func FromContext(ctx context.Context) *Context {
+	rc, _ := ctx.Value(ctxKey{}).(*Context)
+	return rc
+}
@@ -2,6 +2,8 @@ package trace
 
 import (
 	"context"
+	"gin-frame/codes"
+	"gin-frame/library/tracer"
 	"github.com/why444216978/go-library/libraries/log"
 	"net/http"
 	"net/url"
@@ -14,6 +16,9 @@ import (
 
 const defaultComponentName = "net/http"
 
+//unmatchedRoute 没有匹配到路由的请求使用的span名，避免按url产生大量不同的span名
+const unmatchedRoute = "unmatched"
+
 type Options struct {
 	tracer        opentracing.Tracer
 	opNameFunc    func(r *http.Request) string
@@ -55,13 +60,12 @@ func WithURLTagFunc(f func(u *url.URL) string) OptionsFunc {
 }
 
 //OpenTracing 链路追踪中间件
-//实现了[opentracing](https://opentracing.io)协议
-//tracer默认使用jaeger.Tracer,如需修改,可用中间件WithTracer
+//实现了[opentracing](https://opentracing.io)协议，同时支持W3C traceparent/tracestate的提取和注入
+//tracer默认使用opentracing.GlobalTracer，由library/tracer按app.ini的[trace]创建并设置，也可用WithTracer指定
+//span名默认为method加路由模板，如 GET /origin/first_origin_price，可用OperationNameFunc指定
+//http状态码>=500或errno不为0时span标记为error
 func OpenTracing(serviceName string, options ...OptionsFunc) gin.HandlerFunc {
 	opts := Options{
-		opNameFunc: func(r *http.Request) string {
-			return r.Proto + " " + r.Method
-		},
 		spanObserver: func(span opentracing.Span, r *http.Request) {},
 		urlTagFunc: func(u *url.URL) string {
 			return u.String()
@@ -76,9 +80,13 @@ func OpenTracing(serviceName string, options ...OptionsFunc) gin.HandlerFunc {
 	}
 
 	return func(c *gin.Context) {
-		carrier := opentracing.HTTPHeadersCarrier(c.Request.Header)
-		spanContext, _ := opts.tracer.Extract(opentracing.HTTPHeaders, carrier)
-		op := opts.opNameFunc(c.Request)
+		spanContext, _ := tracer.Extract(opts.tracer, c.Request.Header)
+		var op string
+		if opts.opNameFunc != nil {
+			op = opts.opNameFunc(c.Request)
+		} else {
+			op = operationName(c)
+		}
 		sp := opts.tracer.StartSpan(op, opentracing.ChildOf(spanContext), opentracing.StartTime(time.Now()))
 		ext.HTTPMethod.Set(sp, c.Request.Method)
 		ext.HTTPUrl.Set(sp, opts.urlTagFunc(c.Request.URL))
@@ -90,27 +98,44 @@ func OpenTracing(serviceName string, options ...OptionsFunc) gin.HandlerFunc {
 		}
 
 		ext.Component.Set(sp, componentName)
-		c.Request = c.Request.WithContext(
-			opentracing.ContextWithSpan(c.Request.Context(), sp))
+		ctx := opentracing.ContextWithSpan(c.Request.Context(), sp)
+		ctx = tracer.ContextWithTraceState(ctx, c.Request.Header.Get(tracer.TraceStateHeader))
+		c.Request = c.Request.WithContext(ctx)
 		//trace info 注入resp.Header
-		sp.Tracer().Inject(sp.Context(), opentracing.HTTPHeaders,
-			opentracing.HTTPHeadersCarrier(c.Writer.Header()))
+		tracer.Inject(ctx, sp, c.Writer.Header())
 		c.Next()
-		ext.HTTPStatusCode.Set(sp, uint16(c.Writer.Status()))
+		status := c.Writer.Status()
+		ext.HTTPStatusCode.Set(sp, uint16(status))
+		errno := 0
+		if v, ok := c.Get(codes.ErrnoKey); ok {
+			errno, _ = v.(int)
+			sp.SetTag("errno", errno)
+		}
+		if status >= http.StatusInternalServerError || errno != 0 {
+			ext.Error.Set(sp, true)
+		}
 		sp.FinishWithOptions(opentracing.FinishOptions{FinishTime: time.Now()})
 	}
 }
 
-//rpc调用时，trace注入header，用于分析当前rpc调用链的各节点耗时
-func InjectTrace(ctx context.Context, logFormater *log.LogFormat, req *http.Request) {
-	span := opentracing.SpanFromContext(ctx)
-	if span == nil {
-		panic("nil span")
+//operationName gin在执行中间件之前已匹配路由，此时即可取到FullPath
+func operationName(c *gin.Context) string {
+	route := c.FullPath()
+	if route == "" {
+		route = unmatchedRoute
 	}
+	return c.Request.Method + " " + route
+}
 
+//rpc调用时，trace注入header，用于分析当前rpc调用链的各节点耗时
+//ctx中没有span(如定时任务)时只设置x-hop，不再panic；一般的出站调用使用library/httpclient
+func InjectTrace(ctx context.Context, logFormater *log.LogFormat, req *http.Request) {
 	//trace info 注入req.Header
-	span.Tracer().Inject(span.Context(), opentracing.HTTPHeaders,
-		opentracing.HTTPHeadersCarrier(req.Header))
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		tracer.Inject(ctx, span, req.Header)
+	}
 
-	req.Header.Set("x-hop", logFormater.XHop.Hex())
+	if logFormater != nil && logFormater.XHop != nil {
+		req.Header.Set("x-hop", logFormater.XHop.Hex())
+	}
 }
@@ -5,44 +5,68 @@
 package base
 
 import (
-	"github.com/why444216978/go-library/libraries/config"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"gin-frame/config"
+
 	"github.com/why444216978/go-library/libraries/mysql"
-	"github.com/why444216978/go-library/libraries/util"
-	util_err "github.com/why444216978/go-library/libraries/util/error"
-	"gopkg.in/ini.v1"
 )
 
-var cfgs map[string]*ini.Section
+var cfgs map[string]*config.MysqlConn
 var dbInstance map[string]*mysql.DB
+var dbLock sync.Mutex
+
+//SetConfig 设置启动时加载的mysql配置，需要在第一次GetInstance之前调用
+func SetConfig(conns map[string]*config.MysqlConn) {
+	dbLock.Lock()
+	defer dbLock.Unlock()
+
+	cfgs = conns
+}
+
+//GetInstance 获取conn对应的读写连接，连接失败返回Kind为ErrConnection的*QueryError
+func GetInstance(conn string) (*mysql.DB, error) {
+	dbLock.Lock()
+	defer dbLock.Unlock()
 
-func GetInstance(conn string) *mysql.DB {
 	if len(dbInstance) == 0 {
 		dbInstance = make(map[string]*mysql.DB, 30)
 	}
 
 	if dbInstance[conn] == nil {
-		dbInstance[conn] = getConn(conn)
+		db, err := getConn(conn)
+		if err != nil {
+			return nil, err
+		}
+		dbInstance[conn] = db
 	}
 
-	return dbInstance[conn]
+	return dbInstance[conn], nil
 }
 
-func getConn(conn string) *mysql.DB {
+func getConn(conn string) (*mysql.DB, error) {
 	write := conn + "_write"
 	read := conn + "_read"
-	writeDsn := getDSN(conn + "_write")
-	readDsn := getDSN(conn + "_read")
+	connCfg, ok := cfgs[conn]
+	if !ok {
+		return nil, &QueryError{Op: "connect", Table: conn, Kind: ErrConnection, Err: fmt.Errorf("mysql conn %s is not configured", conn)}
+	}
 
 	writeObj := mysql.Conn{
-		DSN:     writeDsn,
-		MaxOpen: getMaxOpen(write),
-		MaxIdle: getMaxIdle(write),
+		DSN:     connCfg.Write.DSN(),
+		MaxOpen: connCfg.Write.MaxOpen,
+		MaxIdle: connCfg.Write.MaxIdle,
 	}
 
 	readObj := mysql.Conn{
-		DSN:     readDsn,
-		MaxOpen: getMaxOpen(read),
-		MaxIdle: getMaxOpen(read),
+		DSN:     connCfg.Read.DSN(),
+		MaxOpen: connCfg.Read.MaxOpen,
+		MaxIdle: connCfg.Read.MaxIdle,
 	}
 
 	cfg := &mysql.Config{
@@ -51,37 +75,66 @@ func getConn(conn string) *mysql.DB {
 	}
 
 	db, err := mysql.New(cfg)
-	util.Must(err)
+	if err != nil {
+		return nil, &QueryError{Op: "connect", Table: conn, Kind: ErrConnection, Err: err}
+	}
+	registerMetrics(db.MasterOrm(), write)
+	registerMetrics(db.SlaveOrm(), read)
+	registerTracing(db.MasterOrm(), write)
+	registerTracing(db.SlaveOrm(), read)
 
-	return db
+	return db, nil
 }
 
-func getMaxOpen(conn string) int {
-	cfg := getCfg(conn)
-	masterNum, err := cfg.Key("max_open").Int()
-	util_err.Must(err)
-	return masterNum
-}
+//CloseAll 关闭所有已创建的读写连接池，进程退出时调用
+func CloseAll() error {
+	dbLock.Lock()
+	defer dbLock.Unlock()
+
+	var problems []string
+	for conn, db := range dbInstance {
+		if err := db.MasterOrm().Close(); err != nil {
+			problems = append(problems, conn+"_write: "+err.Error())
+		}
+		if err := db.SlaveOrm().Close(); err != nil {
+			problems = append(problems, conn+"_read: "+err.Error())
+		}
+	}
+	dbInstance = make(map[string]*mysql.DB, 30)
 
-func getMaxIdle(conn string) int {
-	cfg := getCfg(conn)
-	masterNum, err := cfg.Key("max_idle").Int()
-	util_err.Must(err)
-	return masterNum
+	if len(problems) > 0 {
+		return errors.New("close mysql: " + strings.Join(problems, ", "))
+	}
+	return nil
 }
 
-func getDSN(conn string) string {
-	cfg := getCfg(conn)
-	dsn := cfg.Key("user").String() + ":" + cfg.Key("password").String() + "@tcp(" + cfg.Key("host").String() + ":" + cfg.Key("port").String() + ")/" + cfg.Key("db").String() + "?charset=" + cfg.Key("charset").String()
-	return dsn
+//Conns 已创建的连接名，按名称排序
+func Conns() []string {
+	dbLock.Lock()
+	defer dbLock.Unlock()
+
+	conns := make([]string, 0, len(dbInstance))
+	for conn := range dbInstance {
+		conns = append(conns, conn)
+	}
+	sort.Strings(conns)
+	return conns
 }
 
-func getCfg(conn string) *ini.Section {
-	if cfgs == nil {
-		cfgs = make(map[string]*ini.Section, 30)
+//PingWrite 探测conn的写库
+func PingWrite(ctx context.Context, conn string) error {
+	db, err := GetInstance(conn)
+	if err != nil {
+		return err
 	}
-	if cfgs[conn] == nil {
-		cfgs[conn] = config.GetConfig("mysql", conn)
+	return db.MasterOrm().DB().PingContext(ctx)
+}
+
+//PingRead 探测conn的读库
+func PingRead(ctx context.Context, conn string) error {
+	db, err := GetInstance(conn)
+	if err != nil {
+		return err
 	}
-	return cfgs[conn]
+	return db.SlaveOrm().DB().PingContext(ctx)
 }
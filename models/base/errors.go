@@ -0,0 +1,87 @@
+package base
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+//数据层错误类型，使用errors.Is判断
+var (
+	ErrNotFound   = errors.New("record not found")
+	ErrTimeout    = errors.New("query timeout")
+	ErrConnection = errors.New("connection failure")
+	ErrDuplicate  = errors.New("duplicate entry")
+	ErrDeadlock   = errors.New("deadlock")
+	ErrQuery      = errors.New("query failure")
+)
+
+//QueryError 数据层错误，Kind为上面的错误类型之一，Err为原始错误
+type QueryError struct {
+	Op    string
+	Table string
+	Kind  error
+	Err   error
+}
+
+func (e *QueryError) Error() string {
+	if e.Table == "" {
+		return fmt.Sprintf("%s: %v: %v", e.Op, e.Kind, e.Err)
+	}
+	return fmt.Sprintf("%s %s: %v: %v", e.Op, e.Table, e.Kind, e.Err)
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+func (e *QueryError) Is(target error) bool {
+	return e.Kind == target
+}
+
+//CheckRes 把gorm的执行结果转换为*QueryError，成功返回nil
+func CheckRes(op, table string, dbRes *gorm.DB) error {
+	if dbRes.Error == nil {
+		return nil
+	}
+	return &QueryError{Op: op, Table: table, Kind: classify(dbRes.Error), Err: dbRes.Error}
+}
+
+func classify(err error) error {
+	if gorm.IsRecordNotFoundError(err) {
+		return ErrNotFound
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrTimeout
+		}
+		return ErrConnection
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || strings.Contains(err.Error(), "invalid connection") {
+		return ErrConnection
+	}
+	//mysql 1205: Lock wait timeout exceeded
+	if strings.Contains(err.Error(), "Error 1205") {
+		return ErrTimeout
+	}
+	//mysql 1213: Deadlock found when trying to get lock，InnoDB已回滚整个事务
+	if strings.Contains(err.Error(), "Error 1213") {
+		return ErrDeadlock
+	}
+	//mysql 1062: Duplicate entry for key
+	if strings.Contains(err.Error(), "Error 1062") {
+		return ErrDuplicate
+	}
+	return ErrQuery
+}
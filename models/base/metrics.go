@@ -0,0 +1,71 @@
+package base
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsStartKey = "metrics:start"
+
+var (
+	queryTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mysql_queries_total",
+		Help: "mysql语句数，conn为mysql.ini的连接名，result为ok、not_found或error",
+	}, []string{"conn", "table", "op", "result"})
+
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mysql_query_duration_seconds",
+		Help:    "mysql语句耗时",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"conn", "table", "op"})
+)
+
+func init() {
+	prometheus.MustRegister(queryTotal, queryDuration)
+}
+
+//registerMetrics 通过gorm callback统计conn上执行的每条语句
+func registerMetrics(db *gorm.DB, conn string) {
+	callback := db.Callback()
+
+	callback.Create().Before("gorm:create").Register("metrics:before_create", beforeQuery)
+	callback.Create().After("gorm:create").Register("metrics:after_create", afterQuery(conn, "create"))
+	callback.Query().Before("gorm:query").Register("metrics:before_query", beforeQuery)
+	callback.Query().After("gorm:query").Register("metrics:after_query", afterQuery(conn, "query"))
+	callback.Update().Before("gorm:update").Register("metrics:before_update", beforeQuery)
+	callback.Update().After("gorm:update").Register("metrics:after_update", afterQuery(conn, "update"))
+	callback.Delete().Before("gorm:delete").Register("metrics:before_delete", beforeQuery)
+	callback.Delete().After("gorm:delete").Register("metrics:after_delete", afterQuery(conn, "delete"))
+	callback.RowQuery().Before("gorm:row_query").Register("metrics:before_row_query", beforeQuery)
+	callback.RowQuery().After("gorm:row_query").Register("metrics:after_row_query", afterQuery(conn, "row_query"))
+}
+
+func beforeQuery(scope *gorm.Scope) {
+	scope.Set(metricsStartKey, time.Now())
+}
+
+func afterQuery(conn, op string) func(scope *gorm.Scope) {
+	return func(scope *gorm.Scope) {
+		v, ok := scope.Get(metricsStartKey)
+		if !ok {
+			return
+		}
+		start, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+
+		table := scope.TableName()
+		result := "ok"
+		switch err := scope.DB().Error; {
+		case gorm.IsRecordNotFoundError(err):
+			result = "not_found"
+		case err != nil:
+			result = "error"
+		}
+		queryTotal.WithLabelValues(conn, table, op, result).Inc()
+		queryDuration.WithLabelValues(conn, table, op).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,77 @@
+package base
+
+import (
+	"context"
+
+	"github.com/jinzhu/gorm"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+const (
+	contextKey = "trace:context"
+	spanKey    = "trace:span"
+)
+
+//WithContext 把请求的ctx带到gorm的scope中，callback据此创建子span
+//model的每个查询都应通过WithContext获取orm
+func WithContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	return db.Set(contextKey, ctx)
+}
+
+//registerTracing 通过gorm callback为conn上的每条语句创建子span，ctx中没有span时不创建
+func registerTracing(db *gorm.DB, conn string) {
+	callback := db.Callback()
+
+	callback.Create().Before("gorm:create").Register("trace:before_create", beforeSpan("create"))
+	callback.Create().After("gorm:create").Register("trace:after_create", afterSpan(conn))
+	callback.Query().Before("gorm:query").Register("trace:before_query", beforeSpan("query"))
+	callback.Query().After("gorm:query").Register("trace:after_query", afterSpan(conn))
+	callback.Update().Before("gorm:update").Register("trace:before_update", beforeSpan("update"))
+	callback.Update().After("gorm:update").Register("trace:after_update", afterSpan(conn))
+	callback.Delete().Before("gorm:delete").Register("trace:before_delete", beforeSpan("delete"))
+	callback.Delete().After("gorm:delete").Register("trace:after_delete", afterSpan(conn))
+	callback.RowQuery().Before("gorm:row_query").Register("trace:before_row_query", beforeSpan("row_query"))
+	callback.RowQuery().After("gorm:row_query").Register("trace:after_row_query", afterSpan(conn))
+}
+
+func beforeSpan(op string) func(scope *gorm.Scope) {
+	return func(scope *gorm.Scope) {
+		v, ok := scope.Get(contextKey)
+		if !ok {
+			return
+		}
+		ctx, ok := v.(context.Context)
+		if !ok || opentracing.SpanFromContext(ctx) == nil {
+			return
+		}
+
+		span, _ := opentracing.StartSpanFromContext(ctx, "mysql "+op+" "+scope.TableName())
+		scope.Set(spanKey, span)
+	}
+}
+
+func afterSpan(conn string) func(scope *gorm.Scope) {
+	return func(scope *gorm.Scope) {
+		v, ok := scope.Get(spanKey)
+		if !ok {
+			return
+		}
+		span, ok := v.(opentracing.Span)
+		if !ok {
+			return
+		}
+
+		ext.DBType.Set(span, "mysql")
+		ext.DBInstance.Set(span, conn)
+		ext.DBStatement.Set(span, scope.SQL)
+		ext.SpanKindRPCClient.Set(span)
+		span.SetTag("db.table", scope.TableName())
+		span.SetTag("db.rows_affected", scope.DB().RowsAffected)
+		if err := scope.DB().Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+			ext.Error.Set(span, true)
+			span.SetTag("error.message", err.Error())
+		}
+		span.Finish()
+	}
+}
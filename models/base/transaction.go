@@ -0,0 +1,46 @@
+package base
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jinzhu/gorm"
+)
+
+//maxDeadlockRetry 事务因死锁被回滚后重新执行的次数
+const maxDeadlockRetry = 2
+
+//Transaction 在db上开启事务执行fn，fn返回error或panic时回滚，语句使用tx并通过WithContext关联ctx
+//死锁时InnoDB已回滚整个事务，重新执行fn，最多maxDeadlockRetry次，fn需要可以重复执行
+func Transaction(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	var err error
+	for i := 0; i <= maxDeadlockRetry; i++ {
+		err = transaction(ctx, db, fn)
+		if !errors.Is(err, ErrDeadlock) {
+			return err
+		}
+	}
+	return err
+}
+
+func transaction(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	tx := WithContext(ctx, db).BeginTx(ctx, nil)
+	if err := CheckRes("begin", "", tx); err != nil {
+		return err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			tx.Rollback()
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := CheckRes("commit", "", tx.Commit()); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
@@ -0,0 +1,6 @@
+-- 同一情报员同一品种每天只能有一条报价，WithCustomerLock之外的写入由该索引兜底
+-- 该索引以customer_id开头，WithCustomerLock的SELECT ... FOR UPDATE只锁定该情报员的报价
+-- 上线前先确认没有重复数据，有则保留id最大的一条：
+--   SELECT customer_id, breed_id, day_time, COUNT(*) FROM origin_price
+--   GROUP BY customer_id, breed_id, day_time HAVING COUNT(*) > 1;
+ALTER TABLE origin_price ADD UNIQUE INDEX uniq_customer_breed_day (customer_id, breed_id, day_time);
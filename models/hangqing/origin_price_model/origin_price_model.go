@@ -1,61 +1,253 @@
 package origin_price_model
 
 import (
+	"context"
+
 	"gin-frame/models/base"
 
 	"github.com/jinzhu/gorm"
 	"github.com/why444216978/go-library/libraries/mysql"
 )
 
+const (
+	OrderById      = "id"
+	OrderByDayTime = "day_time"
+)
+
+//OriginPriceQuery 报价列表查询条件，零值字段不参与过滤
+type OriginPriceQuery struct {
+	CustomerId   int
+	ProductId    int
+	BreedId      int
+	ProvinceId   int
+	CityId       int
+	CountyId     int
+	LocationId   int
+	DayTimeStart string
+	DayTimeEnd   string
+	Status       *int
+
+	//OrderBy 排序字段，OrderById或OrderByDayTime，默认OrderByDayTime，始终以id作为第二排序字段保证顺序稳定
+	OrderBy string
+	Asc     bool
+
+	Limit  int
+	Offset int
+	//Cursor 不为nil时使用游标分页，忽略Offset
+	Cursor *OriginPriceCursor
+}
+
+//OriginPriceCursor 上一页最后一条记录的排序字段，以及生成游标时的排序方式
+type OriginPriceCursor struct {
+	OrderBy string
+	Asc     bool
+	DayTime string
+	Id      int
+}
+
 type OriginPrice struct {
 	//gorm.Model
-	Id            int `gorm:"primary_key"`
-	Customer_id   int
-	Province_id   int
-	City_id       int
-	County_id     int
-	Location_id   int
-	Product_id    int
-	Breed_id      int
-	Point_key     string
-	Day_time      string
-	Price_list    string
-	Desc_list     string
-	Status        int
-	Created_time  int
-	Updated_time  int
-	Refuse_reason string
-	Is_sync       int
+	Id            int    `gorm:"primary_key" json:"id"`
+	Customer_id   int    `json:"customer_id"`
+	Province_id   int    `json:"province_id"`
+	City_id       int    `json:"city_id"`
+	County_id     int    `json:"county_id"`
+	Location_id   int    `json:"location_id"`
+	Product_id    int    `json:"product_id"`
+	Breed_id      int    `json:"breed_id"`
+	Point_key     string `json:"point_key"`
+	Day_time      string `json:"day_time"`
+	Price_list    string `json:"price_list"`
+	Desc_list     string `json:"desc_list"`
+	Status        int    `json:"status"`
+	Created_time  int    `json:"created_time"`
+	Updated_time  int    `json:"updated_time"`
+	Refuse_reason string `json:"refuse_reason"`
+	Is_sync       int    `json:"is_sync"`
 }
 
 func (OriginPrice) TableName() string {
 	return "origin_price"
 }
 
+//DetailProductId 查询品类详情用的ID，有品种时取品种，否则取品类
+func (o *OriginPrice) DetailProductId() int {
+	if o.Breed_id != 0 {
+		return o.Breed_id
+	}
+	return o.Product_id
+}
+
+//Conn mysql.ini中的连接名
+const Conn = "hangqing"
+
 type OriginPriceModel struct {
 	Db *mysql.DB
 }
 
-var instance *OriginPriceModel
+func NewOriginPriceModel() (*OriginPriceModel, error) {
+	db, err := base.GetInstance(Conn)
+	if err != nil {
+		return nil, err
+	}
+
+	instance := &OriginPriceModel{}
+	instance.Db = db
+	return instance, nil
+}
 
-func NewOriginPriceModel() *OriginPriceModel {
-	if instance == nil {
-		instance = &OriginPriceModel{}
-		instance.Db = base.GetInstance("hangqing")
+//GetFirst 没有数据时返回base.ErrNotFound
+func (instance *OriginPriceModel) GetFirst(ctx context.Context) (*OriginPrice, error) {
+	originPrice := &OriginPrice{}
+	orm := base.WithContext(ctx, instance.Db.SlaveOrm())
+	dbRes := orm.First(originPrice)
+	if err := base.CheckRes("GetFirst", originPrice.TableName(), dbRes); err != nil {
+		return nil, err
 	}
-	return instance
+	return originPrice, nil
 }
 
-func (instance *OriginPriceModel) GetFirst() []OriginPrice {
+//GetMasterById 从主库读取，写操作前的校验使用，避免主从延迟
+func (instance *OriginPriceModel) GetMasterById(ctx context.Context, id int) (*OriginPrice, error) {
+	originPrice := &OriginPrice{}
+	orm := base.WithContext(ctx, instance.Db.MasterOrm())
+	dbRes := orm.Where("id = ?", id).First(originPrice)
+	if err := base.CheckRes("GetMasterById", originPrice.TableName(), dbRes); err != nil {
+		return nil, err
+	}
+	return originPrice, nil
+}
+
+//CustomerTx 锁定了情报员全部报价的事务，由WithCustomerLock创建
+type CustomerTx interface {
+	//Prices 情报员已有的报价，只包含id、breed_id、day_time，按id升序
+	Prices() []OriginPrice
+	//Create 唯一索引冲突返回base.ErrDuplicate
+	Create(originPrice *OriginPrice) error
+	//Update 按主键更新fields中的字段
+	Update(originPrice *OriginPrice, fields map[string]interface{}) error
+}
+
+type customerTx struct {
+	orm    *gorm.DB
+	prices []OriginPrice
+}
+
+func (tx *customerTx) Prices() []OriginPrice {
+	return tx.prices
+}
+
+func (tx *customerTx) Create(originPrice *OriginPrice) error {
+	return base.CheckRes("Create", originPrice.TableName(), tx.orm.Create(originPrice))
+}
+
+func (tx *customerTx) Update(originPrice *OriginPrice, fields map[string]interface{}) error {
+	return base.CheckRes("Update", originPrice.TableName(), tx.orm.Model(originPrice).Updates(fields))
+}
+
+//WithCustomerLock 在主库事务中以SELECT ... FOR UPDATE锁定情报员的报价后执行fn，fn返回error时回滚
+//同一情报员的新增和修改串行执行，锁随事务提交或回滚释放
+//情报员还没有报价时并发新增可能死锁，由base.Transaction重试，fn可能执行多次
+func (instance *OriginPriceModel) WithCustomerLock(ctx context.Context, customerId int, fn func(tx CustomerTx) error) error {
+	return base.Transaction(ctx, instance.Db.MasterOrm(), func(orm *gorm.DB) error {
+		tx := &customerTx{orm: orm}
+		dbRes := orm.Set("gorm:query_option", "FOR UPDATE").
+			Select("id, breed_id, day_time").
+			Where("customer_id = ?", customerId).
+			Order("id").
+			Find(&tx.prices)
+		if err := base.CheckRes("WithCustomerLock", OriginPrice{}.TableName(), dbRes); err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
+
+//List 按条件查询报价列表
+func (instance *OriginPriceModel) List(ctx context.Context, query *OriginPriceQuery) ([]OriginPrice, error) {
 	originPrices := []OriginPrice{}
-	orm := instance.Db.SlaveOrm()
-	dbRes := orm.First(&originPrices)
-	instance.checkRes(dbRes)
-	return originPrices
+	orm := instance.where(base.WithContext(ctx, instance.Db.SlaveOrm()), query)
+	orm = instance.order(orm, query)
+	if query.Limit > 0 {
+		orm = orm.Limit(query.Limit)
+	}
+	if query.Cursor == nil && query.Offset > 0 {
+		orm = orm.Offset(query.Offset)
+	}
+
+	dbRes := orm.Find(&originPrices)
+	if err := base.CheckRes("List", OriginPrice{}.TableName(), dbRes); err != nil {
+		return nil, err
+	}
+	return originPrices, nil
+}
+
+//Count 按条件统计报价数量，忽略分页参数
+func (instance *OriginPriceModel) Count(ctx context.Context, query *OriginPriceQuery) (int, error) {
+	count := 0
+	filter := *query
+	filter.Cursor = nil
+	orm := instance.where(base.WithContext(ctx, instance.Db.SlaveOrm()).Model(&OriginPrice{}), &filter)
+
+	dbRes := orm.Count(&count)
+	if err := base.CheckRes("Count", OriginPrice{}.TableName(), dbRes); err != nil {
+		return 0, err
+	}
+	return count, nil
 }
 
-func (instance *OriginPriceModel) checkRes(dbRes *gorm.DB) {
-	if dbRes.Error != nil {
-		panic(dbRes.Error)
+func (instance *OriginPriceModel) where(orm *gorm.DB, query *OriginPriceQuery) *gorm.DB {
+	equals := []struct {
+		column string
+		value  int
+	}{
+		{"customer_id", query.CustomerId},
+		{"product_id", query.ProductId},
+		{"breed_id", query.BreedId},
+		{"province_id", query.ProvinceId},
+		{"city_id", query.CityId},
+		{"county_id", query.CountyId},
+		{"location_id", query.LocationId},
+	}
+	for _, v := range equals {
+		if v.value != 0 {
+			orm = orm.Where(v.column+" = ?", v.value)
+		}
+	}
+
+	if query.DayTimeStart != "" {
+		orm = orm.Where("day_time >= ?", query.DayTimeStart)
+	}
+	if query.DayTimeEnd != "" {
+		orm = orm.Where("day_time <= ?", query.DayTimeEnd)
+	}
+	if query.Status != nil {
+		orm = orm.Where("status = ?", *query.Status)
+	}
+
+	if query.Cursor != nil {
+		op := "<"
+		if query.Asc {
+			op = ">"
+		}
+		if query.OrderBy == OrderById {
+			orm = orm.Where("id "+op+" ?", query.Cursor.Id)
+		} else {
+			orm = orm.Where("(day_time "+op+" ?) OR (day_time = ? AND id "+op+" ?)",
+				query.Cursor.DayTime, query.Cursor.DayTime, query.Cursor.Id)
+		}
+	}
+	return orm
+}
+
+func (instance *OriginPriceModel) order(orm *gorm.DB, query *OriginPriceQuery) *gorm.DB {
+	direction := " DESC"
+	if query.Asc {
+		direction = " ASC"
+	}
+
+	if query.OrderBy != OrderById {
+		orm = orm.Order("day_time" + direction)
 	}
+	return orm.Order("id" + direction)
 }
@@ -1,57 +1,116 @@
 package routers
 
 import (
+	"gin-frame/config"
+	"gin-frame/container"
 	"gin-frame/controllers/base"
+	health_controller "gin-frame/controllers/health"
 	"gin-frame/controllers/price"
+	"gin-frame/health"
+	"gin-frame/library/alert"
+	"gin-frame/library/logger"
+	"gin-frame/library/tracer"
+	"gin-frame/middlewares/auth"
 	"gin-frame/middlewares/log"
+	"gin-frame/middlewares/metrics"
 	"gin-frame/middlewares/panic"
+	"gin-frame/middlewares/request"
 	"gin-frame/middlewares/trace"
+	"gin-frame/service/origin_price_service"
+	"gin-frame/shutdown"
 
 	"github.com/gin-gonic/gin"
-	"github.com/why444216978/go-library/libraries/config"
-	"github.com/why444216978/go-library/libraries/util"
 )
 
-func InitRouter(port int, productName, moduleName, env string) *gin.Engine {
+func InitRouter(port int, productName, moduleName, env string, c *container.Container) *gin.Engine {
 	server := gin.New()
 
-	server.Use(gin.Recovery())
-
-	server.Use(trace.OpenTracing(productName))
-
-	logFields := make(map[string]string, 3)
-	logFieldsSection := "log_fields"
-	logFieldsConfig := config.GetConfig("log", logFieldsSection)
-	logFields["query_id"] = logFieldsConfig.Key("query_id").String()
-	logFields["header_id"] = logFieldsConfig.Key("header_id").String()
-	logFields["header_hop"] = logFieldsConfig.Key("header_hop").String()
-
-	runLogSection := "run"
-	runLogConfig := config.GetConfig("log", runLogSection)
-	runLogDir := runLogConfig.Key("dir").String()
-	runLogArea, _ := runLogConfig.Key("area").Int()
-	server.Use(log.LoggerMiddleware(port, logFields, runLogDir, runLogArea, productName, moduleName, env))
-
-	errLogSection := "error"
-	errorLogConfig := config.GetConfig("log", errLogSection)
-	errorLogDir := errorLogConfig.Key("dir").String()
-	errorLogArea, err := errorLogConfig.Key("area").Int()
-	util.Must(err)
-	server.Use(panic.ThrowPanic(port, logFields, errorLogDir, errorLogArea, productName, moduleName, env))
+	var cfg *config.Config
+	c.MustResolve(&cfg)
+
+	var loggers *logger.Loggers
+	c.MustResolve(&loggers)
+	var notifier *alert.Notifier
+	c.MustResolve(&notifier)
+
+	requestLog := cfg.Log.Request
+	redactor := logger.NewRedactor(requestLog.RedactHeaders, requestLog.RedactFields, requestLog.RedactPhone)
+
+	//同一个ThrowPanic注册在最外层和日志之后，共用去重状态
+	//handler的panic由内层处理，日志、指标和trace仍能记录到500；最外层兜底中间件自身的panic
+	recovery := panic.ThrowPanic(loggers.Error, redactor, notifier, cfg.Alert.DedupWindow)
+	server.Use(recovery)
+
+	var coordinator *shutdown.Coordinator
+	c.MustResolve(&coordinator)
+	server.Use(coordinator.Middleware())
+
+	server.Use(metrics.Metrics())
+
+	var t *tracer.Tracer
+	c.MustResolve(&t)
+	server.Use(trace.OpenTracing(productName, trace.WithTracer(t)))
+
+	logFields := map[string]string{
+		"query_id":   cfg.Log.Fields.QueryId,
+		"header_id":  cfg.Log.Fields.HeaderId,
+		"header_hop": cfg.Log.Fields.HeaderHop,
+	}
+	server.Use(request.RequestContext(port, logFields, productName, moduleName, env))
+
+	skipRoutes := make(map[string]bool)
+	for _, route := range requestLog.SkipBody {
+		skipRoutes[route] = true
+	}
+	bodyOptions := &log.BodyOptions{
+		MaxBody:    requestLog.MaxBody,
+		SkipRoutes: skipRoutes,
+		Redactor:   redactor,
+	}
+	server.Use(log.LoggerMiddleware(loggers.Run, requestLog.SlowThreshold, bodyOptions))
+
+	server.Use(recovery)
 	//server.Use(dump.BodyDump())
 
-	baseController := &base.BaseController{}
-	firstOriginPriceController := &price.FirstOriginPriceController{}
+	var originPriceService *origin_price_service.OriginPriceService
+	c.MustResolve(&originPriceService)
+	var spyStore auth.SpyStore
+	c.MustResolve(&spyStore)
+	var checker *health.Checker
+	c.MustResolve(&checker)
 
 	group := server.Group("")
-	group.GET("/ping", func(c *gin.Context) {
-		baseController.Init(c, productName, "ping")
-		baseController.Ping()
-	})
-
-	group.GET("/origin/first_origin_price", func(c *gin.Context) {
-		firstOriginPriceController.Init(c, productName, moduleName)
-		firstOriginPriceController.Do()
-	})
+	group.GET("/ping", base.Handle(productName, "ping", func() base.Controller {
+		return &base.PingController{}
+	}))
+
+	group.GET("/healthz", base.Handle(productName, "health", func() base.Controller {
+		return &health_controller.HealthController{Checker: checker}
+	}))
+
+	group.GET("/metrics", metrics.Handler())
+
+	group.GET("/readyz", base.Handle(productName, "health", func() base.Controller {
+		return &health_controller.ReadyController{Checker: checker, Coordinator: coordinator}
+	}))
+
+	group.GET("/origin/first_origin_price", base.Handle(productName, moduleName, func() base.Controller {
+		return &price.FirstOriginPriceController{OriginPriceService: originPriceService, BypassToken: cfg.Cache.BypassToken}
+	}))
+
+	group.GET("/origin/prices", base.Handle(productName, moduleName, func() base.Controller {
+		return &price.OriginPriceListController{OriginPriceService: originPriceService, Logger: loggers.Run}
+	}))
+
+	//写接口只允许情报员访问
+	spyGroup := group.Group("", auth.SpyAuth(spyStore))
+
+	spyGroup.POST("/origin/prices", base.Handle(productName, moduleName, func() base.Controller {
+		return &price.OriginPriceCreateController{OriginPriceService: originPriceService}
+	}))
+
+	spyGroup.PUT("/origin/prices/:id", base.Handle(productName, moduleName, func() base.Controller {
+		return &price.OriginPriceUpdateController{OriginPriceService: originPriceService}
+	}))
 	return server
 }
@@ -0,0 +1,71 @@
+package origin_price_service
+
+import (
+	"context"
+	"gin-frame/codes"
+	"gin-frame/library/loader"
+	"gin-frame/library/location"
+	"gin-frame/library/product"
+	"gin-frame/models/hangqing/origin_price_model"
+	"sync"
+)
+
+//Loaders 请求级别的品类和地区加载器，由controller在每个请求中创建一次
+type Loaders struct {
+	Product  *loader.Loader
+	Location *loader.Loader
+}
+
+func (self *OriginPriceService) NewLoaders() *Loaders {
+	return &Loaders{
+		Product:  loader.NewMap(self.productService.BatchProductDetail, 0, 0),
+		Location: loader.NewMap(self.locationService.BatchLocationDetail, 0, 0),
+	}
+}
+
+//Enrichment 报价列表关联的品类和地区，Missing为缓存中不存在的ID，由调用方记录告警
+type Enrichment struct {
+	Products         map[int]*product.ProductDetail
+	Locations        map[int]*location.LocationDetail
+	MissingProducts  []int
+	MissingLocations []int
+}
+
+//EnrichOriginPrices 批量查询报价列表关联的品类和地区，品类和地区各一次MGET
+func (self *OriginPriceService) EnrichOriginPrices(ctx context.Context, loaders *Loaders, list []origin_price_model.OriginPrice) (*Enrichment, error) {
+	productIds := make([]int, 0, len(list))
+	locationIds := make([]int, 0, len(list))
+	for i := range list {
+		productIds = append(productIds, list[i].DetailProductId())
+		locationIds = append(locationIds, list[i].Location_id)
+	}
+
+	enrichment := &Enrichment{
+		Products:  make(map[int]*product.ProductDetail, len(productIds)),
+		Locations: make(map[int]*location.LocationDetail, len(locationIds)),
+	}
+	var productErr, locationErr error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		productErr = loaders.Product.LoadManyInto(ctx, productIds, enrichment.Products)
+	}()
+
+	go func() {
+		defer wg.Done()
+		locationErr = loaders.Location.LoadManyInto(ctx, locationIds, enrichment.Locations)
+	}()
+	wg.Wait()
+
+	if productErr != nil {
+		return nil, codes.Wrap(codes.ERRNO_DATA_ERR, productErr)
+	}
+	if locationErr != nil {
+		return nil, codes.Wrap(codes.ERRNO_DATA_ERR, locationErr)
+	}
+
+	enrichment.MissingProducts = loaders.Product.Missing()
+	enrichment.MissingLocations = loaders.Location.Missing()
+	return enrichment, nil
+}
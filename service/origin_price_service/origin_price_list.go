@@ -0,0 +1,135 @@
+package origin_price_service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"gin-frame/codes"
+	"gin-frame/models/hangqing/origin_price_model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+//OriginPricePage 报价列表的一页
+//游标分页时Total为-1，调用方使用NextCursor翻页
+type OriginPricePage struct {
+	List       []origin_price_model.OriginPrice
+	HasMore    bool
+	NextCursor string
+	Total      int
+}
+
+//ListOriginPrices 按条件分页查询报价
+//cursor不为空时使用游标分页，否则使用query.Offset分页并返回总数
+func (self *OriginPriceService) ListOriginPrices(ctx context.Context, query *origin_price_model.OriginPriceQuery, cursor string) (*OriginPricePage, error) {
+	q := *query
+	if q.Limit <= 0 {
+		q.Limit = defaultListLimit
+	}
+	if q.Limit > maxListLimit {
+		q.Limit = maxListLimit
+	}
+	limit := q.Limit
+
+	if q.OrderBy != origin_price_model.OrderById {
+		q.OrderBy = origin_price_model.OrderByDayTime
+	}
+
+	if cursor != "" {
+		c, err := DecodeCursor(cursor)
+		if err != nil {
+			return nil, codes.New(codes.ERRNO_PARAMS_EMPTY).WithMsg("invalid cursor").WithCause(err)
+		}
+		//游标中的位置只在生成它的排序方式下有意义
+		if c.OrderBy != q.OrderBy || c.Asc != q.Asc {
+			return nil, codes.New(codes.ERRNO_PARAMS_EMPTY).WithMsg("cursor does not match order_by and sort")
+		}
+		q.Cursor = c
+	}
+
+	//多取一条判断是否还有下一页
+	q.Limit = limit + 1
+	list, err := self.originPriceDao.GetList(ctx, &q)
+	if err != nil {
+		return nil, codes.Wrap(codes.ERRNO_DATA_ERR, err)
+	}
+
+	page := &OriginPricePage{Total: -1}
+	if len(list) > limit {
+		page.HasMore = true
+		list = list[:limit]
+	}
+	page.List = list
+	if page.HasMore {
+		last := list[len(list)-1]
+		page.NextCursor = EncodeCursor(&origin_price_model.OriginPriceCursor{OrderBy: q.OrderBy, Asc: q.Asc, DayTime: last.Day_time, Id: last.Id})
+	}
+
+	if q.Cursor == nil {
+		total, err := self.originPriceDao.GetCount(ctx, &q)
+		if err != nil {
+			return nil, codes.Wrap(codes.ERRNO_DATA_ERR, err)
+		}
+		page.Total = total
+	}
+
+	return page, nil
+}
+
+const (
+	cursorAsc  = "asc"
+	cursorDesc = "desc"
+)
+
+//EncodeCursor 游标对调用方不透明，格式为base64(order_by|asc或desc|day_time|id)
+func EncodeCursor(c *origin_price_model.OriginPriceCursor) string {
+	direction := cursorDesc
+	if c.Asc {
+		direction = cursorAsc
+	}
+	raw := strings.Join([]string{c.OrderBy, direction, c.DayTime, strconv.Itoa(c.Id)}, "|")
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+//DecodeCursor 只校验游标本身，是否与当前查询的排序一致由调用方判断
+func DecodeCursor(cursor string) (*origin_price_model.OriginPriceCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("malformed cursor %q", raw)
+	}
+
+	c := &origin_price_model.OriginPriceCursor{OrderBy: parts[0], DayTime: parts[2]}
+	switch c.OrderBy {
+	case origin_price_model.OrderById, origin_price_model.OrderByDayTime:
+	default:
+		return nil, fmt.Errorf("unknown cursor order_by %q", c.OrderBy)
+	}
+	switch parts[1] {
+	case cursorAsc:
+		c.Asc = true
+	case cursorDesc:
+	default:
+		return nil, fmt.Errorf("unknown cursor direction %q", parts[1])
+	}
+	if _, err := time.Parse(dayLayout, c.DayTime); err != nil {
+		return nil, fmt.Errorf("cursor day_time: %w", err)
+	}
+	if c.Id, err = strconv.Atoi(parts[3]); err != nil {
+		return nil, fmt.Errorf("cursor id: %w", err)
+	}
+	if c.Id <= 0 {
+		return nil, fmt.Errorf("cursor id %d must be > 0", c.Id)
+	}
+	return c, nil
+}
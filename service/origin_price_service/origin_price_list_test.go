@@ -0,0 +1,77 @@
+package origin_price_service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+
+	"gin-frame/codes"
+	"gin-frame/models/hangqing/origin_price_model"
+)
+
+func encodeRaw(raw string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursors := []*origin_price_model.OriginPriceCursor{
+		{OrderBy: origin_price_model.OrderByDayTime, Asc: false, DayTime: "2020-08-01", Id: 10},
+		{OrderBy: origin_price_model.OrderById, Asc: true, DayTime: "2020-08-01", Id: 1},
+	}
+	for _, c := range cursors {
+		got, err := DecodeCursor(EncodeCursor(c))
+		if err != nil {
+			t.Fatalf("DecodeCursor(%+v): %v", c, err)
+		}
+		if !reflect.DeepEqual(got, c) {
+			t.Errorf("round trip = %+v, want %+v", got, c)
+		}
+	}
+}
+
+func TestDecodeCursorErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "!!!"},
+		{"old format", encodeRaw("2020-08-01|10")},
+		{"extra field", encodeRaw("day_time|desc|2020-08-01|10|1")},
+		{"unknown order_by", encodeRaw("price|desc|2020-08-01|10")},
+		{"unknown direction", encodeRaw("day_time|up|2020-08-01|10")},
+		{"bad day_time", encodeRaw("day_time|desc|2020/08/01|10")},
+		{"bad id", encodeRaw("day_time|desc|2020-08-01|abc")},
+		{"zero id", encodeRaw("day_time|desc|2020-08-01|0")},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got, err := DecodeCursor(c.cursor); err == nil {
+				t.Errorf("DecodeCursor(%q) = %+v, want error", c.cursor, got)
+			}
+		})
+	}
+}
+
+func TestListOriginPricesCursorMismatch(t *testing.T) {
+	cursor := EncodeCursor(&origin_price_model.OriginPriceCursor{OrderBy: origin_price_model.OrderByDayTime, DayTime: "2020-08-01", Id: 10})
+	cases := []struct {
+		name  string
+		query *origin_price_model.OriginPriceQuery
+	}{
+		{"order_by", &origin_price_model.OriginPriceQuery{OrderBy: origin_price_model.OrderById}},
+		{"direction", &origin_price_model.OriginPriceQuery{Asc: true}},
+	}
+
+	service := &OriginPriceService{}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := service.ListOriginPrices(context.Background(), c.query, cursor)
+			var codeErr *codes.Error
+			if !errors.As(err, &codeErr) || codeErr.Errno != codes.ERRNO_PARAMS_EMPTY {
+				t.Errorf("ListOriginPrices err = %v, want ERRNO_PARAMS_EMPTY", err)
+			}
+		})
+	}
+}
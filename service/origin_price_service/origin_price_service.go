@@ -2,53 +2,79 @@ package origin_price_service
 
 import (
 	"context"
-	"gin-frame/dao"
-	"gin-frame/dao/origin_price_dao"
-	"gin-frame/library"
+	"errors"
+	"gin-frame/codes"
 	"gin-frame/library/location"
 	"gin-frame/library/product"
+	"gin-frame/models/base"
+	"gin-frame/models/hangqing/origin_price_model"
 	"log"
-	"sync"
 )
 
-type OriginPriceService struct {
-	productService  *product.ProductLibrary
-	locationService *location.LocationLibrary
-	originPriceDao  *origin_price_dao.OriginPriceDao
+//OriginPriceStore 报价数据源，默认由origin_price_dao.OriginPriceDao实现
+type OriginPriceStore interface {
+	GetFirstRow(ctx context.Context, noCache bool) (*origin_price_model.OriginPrice, error)
+	GetList(ctx context.Context, query *origin_price_model.OriginPriceQuery) ([]origin_price_model.OriginPrice, error)
+	GetCount(ctx context.Context, query *origin_price_model.OriginPriceQuery) (int, error)
+
+	GetMasterRow(ctx context.Context, id int) (*origin_price_model.OriginPrice, error)
+	//WithCustomerLock 锁定情报员的报价后执行fn，同一情报员的新增和修改串行执行
+	WithCustomerLock(ctx context.Context, customerId int, fn func(tx origin_price_model.CustomerTx) error) error
 }
 
-var onceOriginPriceService sync.Once
-var originPriceService *OriginPriceService
+//ProductStore 品类数据源，默认由product.ProductLibrary实现
+type ProductStore interface {
+	GetProductDetail(ctx context.Context, id int) (*product.ProductDetail, error)
+	BatchProductDetail(ctx context.Context, ids []int) (map[int]*product.ProductDetail, error)
+}
 
-func NewObj() *OriginPriceService {
-	onceOriginPriceService.Do(func() {
-		originPriceService = &OriginPriceService{}
+//LocationStore 地区数据源，默认由location.LocationLibrary实现
+type LocationStore interface {
+	GetLocationDetail(ctx context.Context, id int) (*location.LocationDetail, error)
+	BatchLocationDetail(ctx context.Context, ids []int) (map[int]*location.LocationDetail, error)
+}
 
-		libraryFactory := &library.LibraryFactory{}
-		locationInterface := libraryFactory.GetInstance("Location")
-		originPriceService.locationService = locationInterface["Location"].(*location.LocationLibrary)
-		productInterface := libraryFactory.GetInstance("Product")
-		originPriceService.productService = productInterface["Product"].(*product.ProductLibrary)
+type OriginPriceService struct {
+	productService  ProductStore
+	locationService LocationStore
+	originPriceDao  OriginPriceStore
+}
 
-		daoFactory := dao.DaoFactory{}
-		originPriceInterface := daoFactory.GetInstance("OriginPriceDao")
-		originPriceService.originPriceDao = originPriceInterface["OriginPriceDao"].(*origin_price_dao.OriginPriceDao)
+func NewOriginPriceService(originPriceDao OriginPriceStore, productService ProductStore, locationService LocationStore) *OriginPriceService {
+	originPriceService := &OriginPriceService{}
+	originPriceService.originPriceDao = originPriceDao
+	originPriceService.productService = productService
+	originPriceService.locationService = locationService
 
-		log.Printf("new origin_price_service")
-	})
+	log.Printf("new origin_price_service")
 
 	return originPriceService
 }
 
-func (self *OriginPriceService) GetFirstRow(noCache bool) map[string]interface{} {
-	return self.originPriceDao.GetFirstRow(true)
+//GetFirstRow 没有报价时返回nil，其余错误返回ERRNO_DATA_ERR
+func (self *OriginPriceService) GetFirstRow(ctx context.Context, noCache bool) (*origin_price_model.OriginPrice, error) {
+	origin, err := self.originPriceDao.GetFirstRow(ctx, noCache)
+	if errors.Is(err, base.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, codes.Wrap(codes.ERRNO_DATA_ERR, err)
+	}
+	return origin, nil
 }
 
-func (self *OriginPriceService) GetOriginPriceLocation(ctx context.Context, locationId int) map[string]interface{} {
-	return self.locationService.GetLocationDetail(ctx, locationId)
+func (self *OriginPriceService) GetOriginPriceLocation(ctx context.Context, locationId int) (*location.LocationDetail, error) {
+	location, err := self.locationService.GetLocationDetail(ctx, locationId)
+	if err != nil {
+		return nil, codes.Wrap(codes.ERRNO_DATA_ERR, err)
+	}
+	return location, nil
 }
 
-func (self *OriginPriceService) GetOriginPriceProduct(ctx context.Context, productId int) map[string]interface{} {
-	return self.productService.GetProductDetail(ctx, productId)
-
+func (self *OriginPriceService) GetOriginPriceProduct(ctx context.Context, productId int) (*product.ProductDetail, error) {
+	product, err := self.productService.GetProductDetail(ctx, productId)
+	if err != nil {
+		return nil, codes.Wrap(codes.ERRNO_DATA_ERR, err)
+	}
+	return product, nil
 }
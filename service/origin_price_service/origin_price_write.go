@@ -0,0 +1,228 @@
+package origin_price_service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gin-frame/codes"
+	"gin-frame/models/base"
+	"gin-frame/models/hangqing/origin_price_model"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	//maxCustomerBreeds 每个情报员最多报价的品种数
+	maxCustomerBreeds = 5
+	//priceEditWindow 报价创建后允许修改的时长
+	priceEditWindow = 24 * time.Hour
+
+	maxPriceItems = 20
+	maxPrice      = 1000000
+	maxDescItems  = 20
+	maxDescLength = 200
+
+	dayLayout = "2006-01-02"
+)
+
+//OriginPriceInput 新增/修改报价的参数，CustomerId为当前情报员
+type OriginPriceInput struct {
+	CustomerId int
+	ProductId  int
+	BreedId    int
+	ProvinceId int
+	CityId     int
+	CountyId   int
+	LocationId int
+	PointKey   string
+	DayTime    string
+	PriceList  string
+	DescList   string
+}
+
+//PriceItem price_list中的一项，price可以是数字或数字字符串
+type PriceItem struct {
+	Spec  string      `json:"spec"`
+	Price json.Number `json:"price"`
+}
+
+//CreateOriginPrice 新增报价，day_time只能是当天
+//同一品种同一天只能报一次，新品种受maxCustomerBreeds限制，校验和写入在情报员的锁内完成
+func (self *OriginPriceService) CreateOriginPrice(ctx context.Context, input *OriginPriceInput) (*origin_price_model.OriginPrice, error) {
+	if err := ValidatePriceList(input.PriceList); err != nil {
+		return nil, err
+	}
+	if err := ValidateDescList(input.DescList); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	day := now.Format(dayLayout)
+	if input.DayTime != "" && input.DayTime != day {
+		return nil, codes.New(codes.ERRNO_WRONG_DAY_TIME)
+	}
+
+	originPrice := &origin_price_model.OriginPrice{
+		Customer_id:  input.CustomerId,
+		Province_id:  input.ProvinceId,
+		City_id:      input.CityId,
+		County_id:    input.CountyId,
+		Location_id:  input.LocationId,
+		Product_id:   input.ProductId,
+		Breed_id:     input.BreedId,
+		Point_key:    input.PointKey,
+		Day_time:     day,
+		Price_list:   input.PriceList,
+		Desc_list:    input.DescList,
+		Created_time: int(now.Unix()),
+		Updated_time: int(now.Unix()),
+	}
+
+	err := self.originPriceDao.WithCustomerLock(ctx, input.CustomerId, func(tx origin_price_model.CustomerTx) error {
+		breedIds := make(map[int]bool)
+		for _, price := range tx.Prices() {
+			if price.Breed_id == input.BreedId && price.Day_time == day {
+				return codes.New(codes.ERRNO_REPEAT_ADD_BREED)
+			}
+			breedIds[price.Breed_id] = true
+		}
+		if !breedIds[input.BreedId] && len(breedIds) >= maxCustomerBreeds {
+			return codes.New(codes.ERRNO_MAX_PRODUCT)
+		}
+
+		//死锁重试时重新写入，不能带上次分配的id
+		originPrice.Id = 0
+		return tx.Create(originPrice)
+	})
+	if err != nil {
+		return nil, writeError(err)
+	}
+	return originPrice, nil
+}
+
+//UpdateOriginPrice 修改报价的price_list和desc_list
+//只能修改自己该品种最新的一条报价，且在创建后priceEditWindow之内，与新增使用同一个情报员的锁
+func (self *OriginPriceService) UpdateOriginPrice(ctx context.Context, id int, input *OriginPriceInput) (*origin_price_model.OriginPrice, error) {
+	if err := ValidatePriceList(input.PriceList); err != nil {
+		return nil, err
+	}
+	if err := ValidateDescList(input.DescList); err != nil {
+		return nil, err
+	}
+
+	originPrice, err := self.originPriceDao.GetMasterRow(ctx, id)
+	if errors.Is(err, base.ErrNotFound) {
+		return nil, codes.New(codes.ERRNO_MISS_PRICE_ID).WithMsg("报价不存在")
+	}
+	if err != nil {
+		return nil, codes.Wrap(codes.ERRNO_DATA_ERR, err)
+	}
+
+	if originPrice.Customer_id != input.CustomerId || originPrice.Breed_id != input.BreedId {
+		return nil, codes.New(codes.ERRNO_WRONG_BREED_ID)
+	}
+
+	now := time.Now()
+	if now.Sub(time.Unix(int64(originPrice.Created_time), 0)) > priceEditWindow {
+		return nil, codes.New(codes.ERRNO_WRONG_OVERTIME)
+	}
+
+	fields := map[string]interface{}{
+		"price_list":   input.PriceList,
+		"desc_list":    input.DescList,
+		"updated_time": int(now.Unix()),
+	}
+	err = self.originPriceDao.WithCustomerLock(ctx, input.CustomerId, func(tx origin_price_model.CustomerTx) error {
+		latestId := 0
+		for _, price := range tx.Prices() {
+			if price.Breed_id == input.BreedId && price.Id > latestId {
+				latestId = price.Id
+			}
+		}
+		if latestId == 0 {
+			return codes.New(codes.ERRNO_CUSTOMER_NOT_HAS_BREED)
+		}
+		if latestId != originPrice.Id {
+			return codes.New(codes.ERRNO_PRICE_ID_NOT_LAST)
+		}
+		return tx.Update(originPrice, fields)
+	})
+	if err != nil {
+		return nil, writeError(err)
+	}
+
+	originPrice.Price_list = input.PriceList
+	originPrice.Desc_list = input.DescList
+	originPrice.Updated_time = int(now.Unix())
+	return originPrice, nil
+}
+
+//writeError 锁内校验返回的*codes.Error原样返回，数据层错误转换为对应的errno
+func writeError(err error) error {
+	var codeErr *codes.Error
+	switch {
+	case errors.As(err, &codeErr):
+		return err
+	//锁之外的写入(如补数据脚本)由(customer_id, breed_id, day_time)唯一索引兜底，见origin_price.sql
+	case errors.Is(err, base.ErrDuplicate):
+		return codes.New(codes.ERRNO_REPEAT_ADD_BREED).WithCause(err)
+	default:
+		return codes.Wrap(codes.ERRNO_DATA_ERR, err)
+	}
+}
+
+//ValidatePriceList price_list必须是非空的json数组，每项有规格和大于0的价格
+func ValidatePriceList(priceList string) error {
+	if strings.TrimSpace(priceList) == "" {
+		return codes.New(codes.ERRNO_MISS_PRICE_LIST)
+	}
+
+	items := []PriceItem{}
+	if err := json.Unmarshal([]byte(priceList), &items); err != nil {
+		return codes.New(codes.ERRNO_WRONG_PRICE_NUM).WithMsg("price_list不是合法的json数组").WithCause(err)
+	}
+	if len(items) == 0 {
+		return codes.New(codes.ERRNO_MISS_PRICE_LIST)
+	}
+	if len(items) > maxPriceItems {
+		return codes.New(codes.ERRNO_WRONG_PRICE_NUM).WithMsg("最多" + strconv.Itoa(maxPriceItems) + "个规格")
+	}
+
+	for _, item := range items {
+		if strings.TrimSpace(item.Spec) == "" {
+			return codes.New(codes.ERRNO_WRONG_PRICE_SPEC)
+		}
+		price, err := item.Price.Float64()
+		if err != nil || price <= 0 || price > maxPrice {
+			return codes.New(codes.ERRNO_WRONG_PRICE_NUM)
+		}
+	}
+	return nil
+}
+
+//ValidateDescList desc_list必须是非空的json字符串数组，每项不超过maxDescLength个字
+func ValidateDescList(descList string) error {
+	if strings.TrimSpace(descList) == "" {
+		return codes.New(codes.ERRNO_MISS_DESC_LIST)
+	}
+
+	items := []string{}
+	if err := json.Unmarshal([]byte(descList), &items); err != nil {
+		return codes.New(codes.ERRNO_WRONG_DESC).WithMsg("desc_list不是合法的json数组").WithCause(err)
+	}
+	if len(items) == 0 {
+		return codes.New(codes.ERRNO_MISS_DESC_LIST)
+	}
+	if len(items) > maxDescItems {
+		return codes.New(codes.ERRNO_WRONG_DESC)
+	}
+
+	for _, item := range items {
+		if strings.TrimSpace(item) == "" || utf8.RuneCountInString(item) > maxDescLength {
+			return codes.New(codes.ERRNO_WRONG_DESC)
+		}
+	}
+	return nil
+}
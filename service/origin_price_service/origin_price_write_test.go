@@ -0,0 +1,224 @@
+package origin_price_service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gin-frame/codes"
+	"gin-frame/models/base"
+	"gin-frame/models/hangqing/origin_price_model"
+)
+
+const (
+	validPriceList = `[{"spec":"一级","price":"3.5"}]`
+	validDescList  = `["个大"]`
+)
+
+//fakeStore 只实现写操作用到的方法，WithCustomerLock直接以prices执行fn
+type fakeStore struct {
+	OriginPriceStore
+
+	rows   map[int]*origin_price_model.OriginPrice
+	prices []origin_price_model.OriginPrice
+	err    error
+
+	created []*origin_price_model.OriginPrice
+	updated map[int]map[string]interface{}
+}
+
+func (s *fakeStore) GetMasterRow(ctx context.Context, id int) (*origin_price_model.OriginPrice, error) {
+	row, ok := s.rows[id]
+	if !ok {
+		return nil, base.ErrNotFound
+	}
+	copied := *row
+	return &copied, nil
+}
+
+func (s *fakeStore) WithCustomerLock(ctx context.Context, customerId int, fn func(tx origin_price_model.CustomerTx) error) error {
+	if s.err != nil {
+		return s.err
+	}
+	return fn(&fakeTx{store: s})
+}
+
+type fakeTx struct {
+	store *fakeStore
+}
+
+func (tx *fakeTx) Prices() []origin_price_model.OriginPrice {
+	return tx.store.prices
+}
+
+func (tx *fakeTx) Create(originPrice *origin_price_model.OriginPrice) error {
+	tx.store.created = append(tx.store.created, originPrice)
+	return nil
+}
+
+func (tx *fakeTx) Update(originPrice *origin_price_model.OriginPrice, fields map[string]interface{}) error {
+	if tx.store.updated == nil {
+		tx.store.updated = make(map[int]map[string]interface{})
+	}
+	tx.store.updated[originPrice.Id] = fields
+	return nil
+}
+
+func errnoOf(err error) int {
+	if err == nil {
+		return 0
+	}
+	return codes.FromError(err).Errno
+}
+
+func TestValidatePriceList(t *testing.T) {
+	tooMany := "[" + strings.TrimSuffix(strings.Repeat(`{"spec":"a","price":1},`, maxPriceItems+1), ",") + "]"
+	cases := []struct {
+		name  string
+		list  string
+		errno int
+	}{
+		{"valid string price", validPriceList, 0},
+		{"valid number price", `[{"spec":"一级","price":3.5},{"spec":"二级","price":2}]`, 0},
+		{"empty", " ", codes.ERRNO_MISS_PRICE_LIST},
+		{"empty array", `[]`, codes.ERRNO_MISS_PRICE_LIST},
+		{"not json", `{"spec"`, codes.ERRNO_WRONG_PRICE_NUM},
+		{"too many items", tooMany, codes.ERRNO_WRONG_PRICE_NUM},
+		{"missing spec", `[{"spec":" ","price":1}]`, codes.ERRNO_WRONG_PRICE_SPEC},
+		{"zero price", `[{"spec":"a","price":0}]`, codes.ERRNO_WRONG_PRICE_NUM},
+		{"negative price", `[{"spec":"a","price":"-1"}]`, codes.ERRNO_WRONG_PRICE_NUM},
+		{"price too large", `[{"spec":"a","price":1000001}]`, codes.ERRNO_WRONG_PRICE_NUM},
+		{"price not a number", `[{"spec":"a","price":"abc"}]`, codes.ERRNO_WRONG_PRICE_NUM},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := errnoOf(ValidatePriceList(c.list)); got != c.errno {
+				t.Errorf("ValidatePriceList(%s) errno = %d, want %d", c.list, got, c.errno)
+			}
+		})
+	}
+}
+
+func TestValidateDescList(t *testing.T) {
+	cases := []struct {
+		name  string
+		list  string
+		errno int
+	}{
+		{"valid", validDescList, 0},
+		{"max length", `["` + strings.Repeat("好", maxDescLength) + `"]`, 0},
+		{"empty", "", codes.ERRNO_MISS_DESC_LIST},
+		{"empty array", `[]`, codes.ERRNO_MISS_DESC_LIST},
+		{"not string array", `[1]`, codes.ERRNO_WRONG_DESC},
+		{"blank item", `["个大"," "]`, codes.ERRNO_WRONG_DESC},
+		{"too long", `["` + strings.Repeat("好", maxDescLength+1) + `"]`, codes.ERRNO_WRONG_DESC},
+		{"too many items", `[` + strings.TrimSuffix(strings.Repeat(`"a",`, maxDescItems+1), ",") + `]`, codes.ERRNO_WRONG_DESC},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := errnoOf(ValidateDescList(c.list)); got != c.errno {
+				t.Errorf("ValidateDescList(%s) errno = %d, want %d", c.list, got, c.errno)
+			}
+		})
+	}
+}
+
+//breedPrices 情报员已报价breedIds，每个品种一条day的报价
+func breedPrices(day string, breedIds ...int) []origin_price_model.OriginPrice {
+	prices := make([]origin_price_model.OriginPrice, 0, len(breedIds))
+	for i, breedId := range breedIds {
+		prices = append(prices, origin_price_model.OriginPrice{Id: i + 1, Breed_id: breedId, Day_time: day})
+	}
+	return prices
+}
+
+func TestCreateOriginPrice(t *testing.T) {
+	today := time.Now().Format(dayLayout)
+	yesterday := time.Now().AddDate(0, 0, -1).Format(dayLayout)
+
+	cases := []struct {
+		name    string
+		prices  []origin_price_model.OriginPrice
+		dayTime string
+		err     error
+		errno   int
+	}{
+		{"first price", nil, "", nil, 0},
+		{"explicit today", nil, today, nil, 0},
+		{"other day", nil, yesterday, nil, codes.ERRNO_WRONG_DAY_TIME},
+		{"same breed yesterday", breedPrices(yesterday, 10), "", nil, 0},
+		{"same breed today", breedPrices(today, 10), "", nil, codes.ERRNO_REPEAT_ADD_BREED},
+		{"new breed under limit", breedPrices(yesterday, 1, 2, 3, 4), "", nil, 0},
+		{"new breed at limit", breedPrices(yesterday, 1, 2, 3, 4, 5), "", nil, codes.ERRNO_MAX_PRODUCT},
+		{"known breed at limit", breedPrices(yesterday, 1, 2, 3, 4, 10), "", nil, 0},
+		{"duplicate entry", nil, "", &base.QueryError{Op: "Create", Kind: base.ErrDuplicate, Err: errors.New("1062")}, codes.ERRNO_REPEAT_ADD_BREED},
+		{"store error", nil, "", &base.QueryError{Op: "Create", Kind: base.ErrConnection, Err: errors.New("bad conn")}, codes.ERRNO_DATA_ERR},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			store := &fakeStore{prices: c.prices, err: c.err}
+			service := &OriginPriceService{originPriceDao: store}
+			input := &OriginPriceInput{CustomerId: 1, ProductId: 2, BreedId: 10, LocationId: 3, DayTime: c.dayTime, PriceList: validPriceList, DescList: validDescList}
+
+			originPrice, err := service.CreateOriginPrice(context.Background(), input)
+			if got := errnoOf(err); got != c.errno {
+				t.Fatalf("CreateOriginPrice errno = %d, want %d (%v)", got, c.errno, err)
+			}
+			if c.errno != 0 {
+				if len(store.created) != 0 {
+					t.Errorf("created %d rows on error", len(store.created))
+				}
+				return
+			}
+			if len(store.created) != 1 || originPrice.Day_time != today || originPrice.Breed_id != 10 {
+				t.Errorf("created = %+v, returned %+v", store.created, originPrice)
+			}
+		})
+	}
+}
+
+func TestUpdateOriginPrice(t *testing.T) {
+	now := time.Now()
+	row := func(id, customerId int, created time.Time) *origin_price_model.OriginPrice {
+		return &origin_price_model.OriginPrice{Id: id, Customer_id: customerId, Breed_id: 10, Created_time: int(created.Unix())}
+	}
+	latest := []origin_price_model.OriginPrice{{Id: 1, Breed_id: 10}, {Id: 2, Breed_id: 10}, {Id: 3, Breed_id: 20}}
+
+	cases := []struct {
+		name   string
+		id     int
+		rows   map[int]*origin_price_model.OriginPrice
+		prices []origin_price_model.OriginPrice
+		errno  int
+	}{
+		{"latest within window", 2, map[int]*origin_price_model.OriginPrice{2: row(2, 1, now.Add(-time.Hour))}, latest, 0},
+		{"not found", 2, nil, latest, codes.ERRNO_MISS_PRICE_ID},
+		{"other customer", 2, map[int]*origin_price_model.OriginPrice{2: row(2, 9, now)}, latest, codes.ERRNO_WRONG_BREED_ID},
+		{"edit window passed", 2, map[int]*origin_price_model.OriginPrice{2: row(2, 1, now.Add(-priceEditWindow-time.Minute))}, latest, codes.ERRNO_WRONG_OVERTIME},
+		{"not latest", 1, map[int]*origin_price_model.OriginPrice{1: row(1, 1, now)}, latest, codes.ERRNO_PRICE_ID_NOT_LAST},
+		{"breed removed", 2, map[int]*origin_price_model.OriginPrice{2: row(2, 1, now)}, nil, codes.ERRNO_CUSTOMER_NOT_HAS_BREED},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			store := &fakeStore{rows: c.rows, prices: c.prices}
+			service := &OriginPriceService{originPriceDao: store}
+			input := &OriginPriceInput{CustomerId: 1, BreedId: 10, PriceList: validPriceList, DescList: validDescList}
+
+			originPrice, err := service.UpdateOriginPrice(context.Background(), c.id, input)
+			if got := errnoOf(err); got != c.errno {
+				t.Fatalf("UpdateOriginPrice errno = %d, want %d (%v)", got, c.errno, err)
+			}
+			if c.errno != 0 {
+				if len(store.updated) != 0 {
+					t.Errorf("updated %v on error", store.updated)
+				}
+				return
+			}
+			if store.updated[c.id]["price_list"] != validPriceList || originPrice.Desc_list != validDescList {
+				t.Errorf("updated = %v, returned %+v", store.updated, originPrice)
+			}
+		})
+	}
+}
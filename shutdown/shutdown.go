@@ -0,0 +1,172 @@
+package shutdown
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+//关闭顺序，数值小的先关闭，同一顺序内按注册顺序
+const (
+	//OrderTracer tracer先flush，保证请求的span都上报
+	OrderTracer = 10
+	//OrderClient mysql、redis、amqp等客户端
+	OrderClient = 20
+	//OrderLog 日志最后关闭，保证关闭过程本身可以记录
+	OrderLog = 30
+)
+
+//Closer 释放资源，ctx到期后应尽快返回
+type Closer func(ctx context.Context) error
+
+type hook struct {
+	name  string
+	order int
+	fn    Closer
+}
+
+//Coordinator 统一管理进程退出时的资源释放
+//endless在SIGTERM/SIGHUP后停止接收新连接，ListenAndServe返回后调用Shutdown：
+//先等待仍在处理中的请求，再按order依次关闭已注册的资源
+type Coordinator struct {
+	drainTimeout time.Duration
+	closeTimeout time.Duration
+
+	lock     sync.Mutex
+	hooks    []hook
+	done     bool
+	inflight int64
+	draining int32
+}
+
+//New drainTimeout为等待处理中请求的最长时间，closeTimeout为每个资源关闭的最长时间
+func New(drainTimeout, closeTimeout time.Duration) *Coordinator {
+	return &Coordinator{
+		drainTimeout: drainTimeout,
+		closeTimeout: closeTimeout,
+	}
+}
+
+//Register 注册需要在退出时释放的资源
+func (c *Coordinator) Register(name string, order int, fn Closer) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.hooks = append(c.hooks, hook{name: name, order: order, fn: fn})
+}
+
+//Middleware 统计处理中的请求数，Shutdown时等待归零
+func (c *Coordinator) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		atomic.AddInt64(&c.inflight, 1)
+		defer atomic.AddInt64(&c.inflight, -1)
+		ctx.Next()
+	}
+}
+
+//Inflight 当前处理中的请求数
+func (c *Coordinator) Inflight() int64 {
+	return atomic.LoadInt64(&c.inflight)
+}
+
+//BeginDrain 标记进程开始退出，readiness随即返回未就绪
+//在endless收到重启/退出信号时调用，早于ListenAndServe返回
+func (c *Coordinator) BeginDrain() {
+	if atomic.CompareAndSwapInt32(&c.draining, 0, 1) {
+		log.Printf("[pid %d] draining, %d requests in flight", syscall.Getpid(), c.Inflight())
+	}
+}
+
+//Draining 是否已经开始退出
+func (c *Coordinator) Draining() bool {
+	return atomic.LoadInt32(&c.draining) == 1
+}
+
+//Shutdown 等待处理中的请求后按顺序释放资源，只执行一次，返回所有关闭失败的资源
+func (c *Coordinator) Shutdown(ctx context.Context) error {
+	c.lock.Lock()
+	if c.done {
+		c.lock.Unlock()
+		return nil
+	}
+	c.done = true
+	hooks := make([]hook, len(c.hooks))
+	copy(hooks, c.hooks)
+	c.lock.Unlock()
+
+	c.BeginDrain()
+	pid := syscall.Getpid()
+	log.Printf("[pid %d] shutdown start, %d requests in flight", pid, c.Inflight())
+	c.drain(ctx, pid)
+
+	sort.SliceStable(hooks, func(i, j int) bool {
+		return hooks[i].order < hooks[j].order
+	})
+
+	var problems []string
+	for _, h := range hooks {
+		start := time.Now()
+		err := c.runHook(ctx, h)
+
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", h.name, err))
+			log.Printf("[pid %d] close %s failed after %s: %v", pid, h.name, time.Since(start), err)
+			continue
+		}
+		log.Printf("[pid %d] close %s done in %s", pid, h.name, time.Since(start))
+	}
+	log.Printf("[pid %d] shutdown finished", pid)
+
+	if len(problems) > 0 {
+		return fmt.Errorf("shutdown: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+//runHook 在独立的goroutine中执行hook，超过closeTimeout不再等待，继续关闭后面的资源
+//不响应ctx的hook会在后台继续运行，进程随后退出
+func (c *Coordinator) runHook(ctx context.Context, h hook) error {
+	hookCtx, cancel := context.WithTimeout(ctx, c.closeTimeout)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				result <- fmt.Errorf("panic: %v", err)
+			}
+		}()
+		result <- h.fn(hookCtx)
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-hookCtx.Done():
+		return fmt.Errorf("not finished in %s: %w", c.closeTimeout, hookCtx.Err())
+	}
+}
+
+func (c *Coordinator) drain(ctx context.Context, pid int) {
+	ctx, cancel := context.WithTimeout(ctx, c.drainTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for c.Inflight() > 0 {
+		select {
+		case <-ctx.Done():
+			log.Printf("[pid %d] drain timeout, %d requests still in flight", pid, c.Inflight())
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package origin_price_view
+
+import (
+	"gin-frame/library/location"
+	"gin-frame/library/product"
+	"gin-frame/models/hangqing/origin_price_model"
+)
+
+//OriginPriceView 对外输出的报价字段，不包含is_sync等内部字段
+type OriginPriceView struct {
+	Id           int    `json:"id"`
+	CustomerId   int    `json:"customer_id"`
+	ProvinceId   int    `json:"province_id"`
+	CityId       int    `json:"city_id"`
+	CountyId     int    `json:"county_id"`
+	LocationId   int    `json:"location_id"`
+	ProductId    int    `json:"product_id"`
+	BreedId      int    `json:"breed_id"`
+	PointKey     string `json:"point_key"`
+	DayTime      string `json:"day_time"`
+	PriceList    string `json:"price_list"`
+	DescList     string `json:"desc_list"`
+	Status       int    `json:"status"`
+	RefuseReason string `json:"refuse_reason"`
+	CreatedTime  int    `json:"created_time"`
+	UpdatedTime  int    `json:"updated_time"`
+}
+
+//OriginPriceItemView 列表中的一条报价，附带品类和地区
+type OriginPriceItemView struct {
+	*OriginPriceView
+	Product  *ProductView  `json:"product"`
+	Location *LocationView `json:"location"`
+}
+
+type ProductView struct {
+	Id       int    `json:"id"`
+	Name     string `json:"name"`
+	ParentId int    `json:"parent_id"`
+	Unit     string `json:"unit"`
+}
+
+type LocationView struct {
+	Id       int    `json:"id"`
+	Name     string `json:"name"`
+	ParentId int    `json:"parent_id"`
+	FullName string `json:"full_name"`
+}
+
+//NewOriginPriceView origin为nil时返回nil，输出为null
+func NewOriginPriceView(origin *origin_price_model.OriginPrice) *OriginPriceView {
+	if origin == nil {
+		return nil
+	}
+
+	return &OriginPriceView{
+		Id:           origin.Id,
+		CustomerId:   origin.Customer_id,
+		ProvinceId:   origin.Province_id,
+		CityId:       origin.City_id,
+		CountyId:     origin.County_id,
+		LocationId:   origin.Location_id,
+		ProductId:    origin.Product_id,
+		BreedId:      origin.Breed_id,
+		PointKey:     origin.Point_key,
+		DayTime:      origin.Day_time,
+		PriceList:    origin.Price_list,
+		DescList:     origin.Desc_list,
+		Status:       origin.Status,
+		RefuseReason: origin.Refuse_reason,
+		CreatedTime:  origin.Created_time,
+		UpdatedTime:  origin.Updated_time,
+	}
+}
+
+func NewOriginPriceItemView(origin *origin_price_model.OriginPrice, product *product.ProductDetail, location *location.LocationDetail) *OriginPriceItemView {
+	return &OriginPriceItemView{
+		OriginPriceView: NewOriginPriceView(origin),
+		Product:         NewProductView(product),
+		Location:        NewLocationView(location),
+	}
+}
+
+func NewProductView(detail *product.ProductDetail) *ProductView {
+	if detail == nil {
+		return nil
+	}
+
+	return &ProductView{
+		Id:       detail.Id,
+		Name:     detail.Name,
+		ParentId: detail.ParentId,
+		Unit:     detail.Unit,
+	}
+}
+
+func NewLocationView(detail *location.LocationDetail) *LocationView {
+	if detail == nil {
+		return nil
+	}
+
+	return &LocationView{
+		Id:       detail.Id,
+		Name:     detail.Name,
+		ParentId: detail.ParentId,
+		FullName: detail.FullName,
+	}
+}